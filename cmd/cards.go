@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"path/filepath"
+
+	"holidaybingo/pkg/cardgen"
+	"holidaybingo/pkg/game"
+)
+
+// cardDealer deals digital cards from the image directory, and finds
+// printed cards in the card manifest so they can be played in the browser
+type cardDealer struct{}
+
+// generator returns a card generator over the current image directory.
+// Digital cards are never printed, so unusable images do not stop a deal.
+func (cardDealer) generator() (*cardgen.Generator, error) {
+	paths, err := listImages(cfg.ImageDir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading image directory: %v", err)
+	}
+	g := cardgen.NewGenerator("")
+	g.SetImages(paths)
+	g.SetCatalog(imageCatalog)
+	g.SetMissingPolicy(cardgen.UsePlaceholder)
+	return g, nil
+}
+
+// Deal returns a new card
+func (d cardDealer) Deal() (game.Card, error) {
+	g, err := d.generator()
+	if err != nil {
+		return game.Card{}, err
+	}
+	cards, err := g.GenerateCards(1)
+	if err != nil {
+		return game.Card{}, err
+	}
+	return digitalCard(cards[0]), nil
+}
+
+// Printed returns the printed card with the given ID from the manifest
+func (cardDealer) Printed(id string) (game.Card, bool) {
+	path := filepath.Join(cfg.CardsDir, cardgen.ManifestFile)
+	cards, err := cardgen.LoadManifest(path)
+	if err != nil {
+		logger.Warn("card manifest not read", "path", path, "err", err)
+		return game.Card{}, false
+	}
+	for _, c := range cards {
+		if c.ID == id && len(c.Squares) == game.CardSquares {
+			return digitalCard(c), true
+		}
+	}
+	return game.Card{}, false
+}
+
+// Page renders card from the HTML card template
+func (d cardDealer) Page(card game.Card, image func(square int) string) (string, error) {
+	g, err := d.generator()
+	if err != nil {
+		return "", err
+	}
+	printed := cardgen.Card{ID: card.ID, Squares: make([]string, game.CardSquares)}
+	for i, id := range card.Squares {
+		printed.Squares[i] = filepath.Join(cfg.ImageDir, id)
+	}
+	return g.RenderHTML(printed, func(square int, imgPath string) string {
+		caption := html.EscapeString(imageCatalog.Caption(filepath.Base(imgPath)))
+		return fmt.Sprintf(`<img src="%s" alt="%s" title="%s">`, image(square), caption, caption)
+	})
+}
+
+// digitalCard converts a generated card to one the engine can play. Its
+// squares hold item IDs, which are the images' file names.
+func digitalCard(c cardgen.Card) game.Card {
+	card := game.Card{ID: c.ID}
+	for i, path := range c.Squares {
+		if i != game.FreeSquare {
+			card.Squares[i] = filepath.Base(path)
+		}
+	}
+	return card
+}
@@ -1,20 +1,44 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
+
 	"holidaybingo/pkg/cardgen"
+	"holidaybingo/pkg/catalog"
+	"holidaybingo/pkg/config"
+	"holidaybingo/pkg/game"
+	"holidaybingo/pkg/imgcache"
+	"holidaybingo/pkg/imgproc"
 )
 
 func main() {
+	count := flag.Int("count", 3, "number of cards to generate")
+	combined := flag.Bool("combined", false, "save all cards to a single PDF")
+	dpi := flag.Int("dpi", cardgen.DefaultDPI, "print resolution of card images")
+	crop := flag.String("crop", imgproc.CropEntropy, "how images without a catalog crop are cut to squares: center or entropy")
+	placeholders := flag.Bool("placeholders", false, "print captions for unusable images instead of failing")
+	master := flag.Bool("master", false, "save the caller master sheet instead of cards")
+	callLog := flag.String("calls", "", "save the call sheet for a game log exported by the caller instead of cards")
+	round := flag.Int("round", 0, "round of the game log to print with -calls (default the last)")
+	flag.Parse()
+
 	// Initialize card generator with template
 	templatePath := filepath.Join("pkg", "cardgen", "templates", "card_template.html.html")
 	generator := cardgen.NewGenerator(templatePath)
 
-	// Get list of actual images from img directory
-	imgDir := filepath.Join("img")
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// Get list of actual images from the image directory
+	imgDir := cfg.ImageDir
 	entries, err := os.ReadDir(imgDir)
 	if err != nil {
 		log.Fatalf("Failed to read image directory: %v", err)
@@ -22,34 +46,116 @@ func main() {
 
 	var images []string
 	for _, entry := range entries {
-		if !entry.IsDir() {
-			// Only include image files
-			if ext := filepath.Ext(entry.Name()); ext == ".png" || ext == ".jpg" || ext == ".jpeg" {
+		if !entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			// Only include image files; hidden files include partial downloads
+			if imgproc.IsImageFile(entry.Name()) {
 				images = append(images, filepath.Join(imgDir, entry.Name()))
 			}
 		}
 	}
 
+	generator.SetImages(images)
+	generator.SetDPI(*dpi)
+	defaultCrop, err := imgproc.ParseCrop(*crop, imgproc.Crop{})
+	if err != nil || defaultCrop.Mode == imgproc.CropFocus {
+		log.Fatalf("Invalid -crop %q: want center or entropy", *crop)
+	}
+	generator.SetCrop(defaultCrop)
+	if *placeholders {
+		generator.SetMissingPolicy(cardgen.UsePlaceholder)
+	}
+	cat, err := catalog.Load(imgDir)
+	if err != nil {
+		log.Fatalf("Failed to load image catalog: %v", err)
+	}
+	generator.SetCatalog(cat)
+	if cache, err := imgcache.New(cfg.CacheDir); err == nil {
+		generator.SetCache(cache)
+	}
+
+	// Caller sheets
+	if *master || *callLog != "" {
+		var outputPath string
+		var report *cardgen.Report
+		if *master {
+			outputPath = filepath.Join(cfg.CardsDir, "HolidayBingo_master_sheet.pdf")
+			report, err = generator.SaveMasterSheet(outputPath)
+		} else {
+			var calls []game.Call
+			if calls, err = readCalls(*callLog, *round); err != nil {
+				log.Fatalf("Failed to read game log: %v", err)
+			}
+			outputPath = filepath.Join(cfg.CardsDir, "HolidayBingo_call_sheet.pdf")
+			report, err = generator.SaveCallSheet(calls, outputPath)
+		}
+		if err != nil {
+			log.Fatalf("Failed to save sheet: %v", err)
+		}
+		if report.HasSubstitutions() {
+			fmt.Printf("Warning: some images were printed as placeholders:\n%s\n", report)
+		}
+		fmt.Printf("Saved %s\n", outputPath)
+		return
+	}
+
 	if len(images) < 24 {
 		log.Fatalf("Not enough images in img directory. Need at least 24, found %d", len(images))
 	}
 
-	generator.SetImages(images)
-
-	// Generate 3 test cards
-	cards, err := generator.GenerateCards(3)
+	// Generate the test cards
+	cards, err := generator.GenerateCards(*count)
 	if err != nil {
 		log.Fatalf("Failed to generate cards: %v", err)
 	}
 
 	// Save cards to PDF files in the cards directory
-	if err := generator.SaveToPDF(cards, "cards"); err != nil {
+	var report *cardgen.Report
+	if *combined {
+		report, err = generator.SaveCombinedPDF(cards, filepath.Join(cfg.CardsDir, "HolidayBingo_cards.pdf"))
+	} else {
+		report, err = generator.SaveToPDF(cards, cfg.CardsDir)
+	}
+	if err != nil {
 		log.Fatalf("Failed to save cards: %v", err)
 	}
+	if report.HasSubstitutions() {
+		fmt.Printf("Warning: some squares were printed as placeholders:\n%s\n", report)
+	}
+
+	// Printed cards can also be played in the browser by their ID
+	manifest := filepath.Join(cfg.CardsDir, cardgen.ManifestFile)
+	if err := cardgen.SaveManifest(cards, manifest); err != nil {
+		log.Fatalf("Failed to save card manifest: %v", err)
+	}
 
-	fmt.Printf("Successfully generated %d cards and saved to cards directory\n", len(cards))
+	fmt.Printf("Successfully generated %d cards and saved to %s\n", len(cards), cfg.CardsDir)
 	fmt.Println("\nCard IDs:")
 	for i, card := range cards {
 		fmt.Printf("Card %d: %s\n", i+1, card.ID)
 	}
 }
+
+// readCalls returns the calls of one round of a game log exported by the
+// caller; round 0 means the last round
+func readCalls(path string, round int) ([]game.Call, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var export game.LogExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	if len(export.Rounds) == 0 {
+		return nil, fmt.Errorf("%s has no rounds", path)
+	}
+	if round == 0 {
+		return export.Rounds[len(export.Rounds)-1].Calls, nil
+	}
+	for _, r := range export.Rounds {
+		if r.Round == round {
+			return r.Calls, nil
+		}
+	}
+	return nil, fmt.Errorf("%s has no round %d", path, round)
+}
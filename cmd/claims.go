@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"holidaybingo/pkg/game"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// updateClaims lists this round's claims under Verify Bingo in the order
+// players made them, and shows how many are waiting on the button
+func updateClaims(s game.Snapshot) {
+	if len(s.Pending) > 0 {
+		verifyButton.SetText(fmt.Sprintf("Verify Bingo (%d)", len(s.Pending)))
+	} else {
+		verifyButton.SetText("Verify Bingo")
+	}
+
+	entries := s.ClaimsByArrival()
+	lines := make([]fyne.CanvasObject, len(entries))
+	for i, c := range entries {
+		label := widget.NewLabel(fmt.Sprintf("%d. %s", i+1, claimText(c)))
+		label.Wrapping = fyne.TextWrapWord
+		if c.Waiting {
+			label.TextStyle.Bold = true
+		}
+		lines[i] = label
+	}
+	claimsBox.Objects = lines
+	claimsBox.Refresh()
+}
+
+// claimText describes a claim for the host's list
+func claimText(c game.ClaimEntry) string {
+	who := c.Player
+	if c.Card != "" {
+		who += " (" + c.Card + ")"
+	}
+	verdict := "not a bingo"
+	switch {
+	case c.Waiting:
+		verdict = "waiting"
+	case c.Valid:
+		verdict = "winner"
+	case c.Reason != "":
+		verdict = c.Reason
+	}
+	return fmt.Sprintf("%s %s - %s", c.Arrived.Format("15:04:05"), who, verdict)
+}
+
+// verifyCardClaim shows the host the squares marked on a digital card that
+// claimed bingo, and records the verdict. The engine has already found the
+// pattern on the card; the host confirms it.
+func verifyCardClaim(pattern game.Pattern, claim game.PendingClaim, waiting int) {
+	card, ok := engine.Card(claim.CardID)
+	if !ok {
+		return
+	}
+	var marked []string
+	for i, id := range card.Squares {
+		if card.Marked[i] && i != game.FreeSquare {
+			marked = append(marked, imageCatalog.Caption(id))
+		}
+	}
+	text := fmt.Sprintf("%s claims bingo on card %s at %s. The called squares on the card make a %s.\n\nMarked (%d): %s",
+		claim.Player, claim.CardID, claim.Time.Format("15:04:05"), pattern, len(marked), strings.Join(marked, ", "))
+	if waiting > 0 {
+		text += fmt.Sprintf("\n\n%d more claims waiting.", waiting)
+	}
+	details := widget.NewLabel(text)
+	details.Wrapping = fyne.TextWrapWord
+
+	title := fmt.Sprintf("Verify Bingo (%s)", pattern)
+	d := dialog.NewCustomConfirm(title, "Winner", "Not a Bingo", details, func(valid bool) {
+		result, err := engine.ResolveClaim(claim.CardID, valid)
+		if errors.Is(err, game.ErrNoBingo) {
+			// A call was undone since the claim arrived
+			dialog.ShowInformation(title, fmt.Sprintf("Card %s no longer shows a %s, so it cannot win.", claim.CardID, pattern), mainWindow)
+			return
+		}
+		if err != nil {
+			logger.Debug("claim already checked", "card", claim.CardID, "err", err)
+			return
+		}
+		logger.Info("claim checked", "player", result.Player, "card", result.Card, "valid", result.Valid, "calls", result.Call)
+	}, mainWindow)
+	d.Resize(fyne.NewSize(500, 300))
+	d.Show()
+}
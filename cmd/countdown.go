@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sync"
+	"time"
+
+	"holidaybingo/pkg/game"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+)
+
+// countdownSize is the diameter of the auto-call ring
+const countdownSize = 48
+
+var (
+	countdownMu       sync.Mutex // Guards countdownDue, countdownInterval and countdownTicking
+	countdownDue      time.Time  // Zero while no automatic call is scheduled
+	countdownInterval time.Duration
+	countdownTicking  bool // The ring is being redrawn every tick
+
+	countdownRing  *canvas.Raster
+	countdownLabel *canvas.Text
+)
+
+var (
+	ringTrack = color.NRGBA{R: 0xdd, G: 0xdd, B: 0xdd, A: 0xff}
+	ringFill  = color.NRGBA{R: 0xc6, G: 0x28, B: 0x28, A: 0xff}
+)
+
+// newCountdown creates the ring that shows the time left until the next
+// automatic call. It stays empty while auto-call is off.
+func newCountdown() fyne.CanvasObject {
+	countdownRing = canvas.NewRaster(ringImage)
+	countdownRing.SetMinSize(fyne.NewSize(countdownSize, countdownSize))
+
+	countdownLabel = canvas.NewText("", color.Black)
+	countdownLabel.TextSize = 14
+	countdownLabel.TextStyle = fyne.TextStyle{Bold: true}
+	countdownLabel.Alignment = fyne.TextAlignCenter
+	return container.NewMax(countdownRing, container.NewCenter(countdownLabel))
+}
+
+// updateCountdown takes the auto-call schedule from the engine's state
+func updateCountdown(s game.Snapshot) {
+	countdownMu.Lock()
+	countdownDue = s.NextAutoCall
+	countdownInterval = s.AutoInterval
+	start := !countdownDue.IsZero() && !countdownTicking
+	if start {
+		countdownTicking = true
+	}
+	countdownMu.Unlock()
+	if start {
+		go tickCountdown()
+	}
+	refreshCountdown()
+}
+
+// tickCountdown redraws the ring every tick until no automatic call is
+// scheduled
+func tickCountdown() {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for range ticker.C {
+		countdownMu.Lock()
+		if countdownDue.IsZero() {
+			countdownTicking = false
+			countdownMu.Unlock()
+			return
+		}
+		countdownMu.Unlock()
+		refreshCountdown()
+	}
+}
+
+// remaining returns the time left until the next automatic call and the
+// fraction of the interval it represents
+func remaining() (time.Duration, float64, bool) {
+	countdownMu.Lock()
+	defer countdownMu.Unlock()
+	if countdownDue.IsZero() || countdownInterval <= 0 {
+		return 0, 0, false
+	}
+	left := time.Until(countdownDue)
+	if left < 0 {
+		left = 0
+	}
+	return left, float64(left) / float64(countdownInterval), true
+}
+
+// refreshCountdown redraws the ring and the seconds left
+func refreshCountdown() {
+	text := ""
+	if left, _, ok := remaining(); ok {
+		text = fmt.Sprintf("%d", int(math.Ceil(left.Seconds())))
+	}
+	if countdownLabel.Text != text {
+		countdownLabel.Text = text
+		countdownLabel.Refresh()
+	}
+	countdownRing.Refresh()
+}
+
+// ringImage draws a frame of the ring, reading the time left once
+func ringImage(w, h int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	_, fraction, ok := remaining()
+	if !ok {
+		return img
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, ringPixel(x, y, w, h, fraction))
+		}
+	}
+	return img
+}
+
+// ringPixel draws a pixel of the ring: the remaining fraction of the
+// interval is filled clockwise from the top, the elapsed part shows the track
+func ringPixel(x, y, w, h int, fraction float64) color.Color {
+	r := math.Min(float64(w), float64(h)) / 2
+	dx, dy := float64(x)-float64(w)/2+0.5, float64(y)-float64(h)/2+0.5
+	d := math.Hypot(dx, dy)
+	if d > r || d < r*0.75 {
+		return color.Transparent
+	}
+
+	// Angle clockwise from twelve o'clock, from 0 to 1
+	angle := math.Atan2(dx, -dy) / (2 * math.Pi)
+	if angle < 0 {
+		angle++
+	}
+	if angle <= fraction {
+		return ringFill
+	}
+	return ringTrack
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"holidaybingo/pkg/cardgen"
+	"holidaybingo/pkg/game"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// exportLog asks for a folder and saves the session's game log there as
+// JSON and CSV, with a PDF summary for the winners email
+func exportLog() {
+	events := engine.Log()
+	if len(events) == 0 {
+		dialog.ShowInformation("Export Log", "Nothing has happened yet this session.", mainWindow)
+		return
+	}
+
+	dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		if dir == nil {
+			return // Cancelled
+		}
+		files, err := writeLogFiles(dir.Path(), events)
+		if err != nil {
+			logger.Error("game log export failed", "dir", dir.Path(), "err", err)
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		logger.Info("game log exported", "dir", dir.Path(), "events", len(events))
+		dialog.ShowInformation("Export Log", "Saved:\n"+strings.Join(files, "\n"), mainWindow)
+	}, mainWindow)
+}
+
+// writeLogFiles writes the JSON, CSV and PDF exports into dir and returns
+// their names
+func writeLogFiles(dir string, events []game.Event) ([]string, error) {
+	base := filepath.Join(dir, "holidaybingo-log-"+time.Now().Format("20060102-150405"))
+
+	writers := []struct {
+		ext   string
+		write func(f *os.File) error
+	}{
+		{".json", func(f *os.File) error { return game.WriteJSON(f, events) }},
+		{".csv", func(f *os.File) error { return game.WriteCSV(f, events) }},
+	}
+
+	var files []string
+	for _, w := range writers {
+		path := base + w.ext
+		f, err := os.Create(path)
+		if err != nil {
+			return files, fmt.Errorf("failed to create %s: %v", path, err)
+		}
+		err = w.write(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return files, err
+		}
+		files = append(files, path)
+	}
+
+	path := base + "-summary.pdf"
+	if err := cardgen.SaveGameSummary(game.Summarize(events), path); err != nil {
+		return files, err
+	}
+	return append(files, path), nil
+}
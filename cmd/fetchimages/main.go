@@ -1,51 +1,147 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
+
+	"holidaybingo/pkg/config"
+	"holidaybingo/pkg/logging"
+	"holidaybingo/pkg/provider"
+	"holidaybingo/pkg/secrets"
 	"holidaybingo/pkg/unsplash"
-	"github.com/joho/godotenv"
 )
 
+var logger = logging.New("fetchimages")
+
+// newProvider creates the image provider selected by the configuration
+func newProvider(cfg *config.Config, field string) (provider.ImageProvider, error) {
+	switch cfg.Provider {
+	case "unsplash":
+		if cfg.UnsplashAPIKey == "" {
+			// Fall back to the secret store, asking for the key if it is not there
+			apiKey, err := secrets.Default(true).Get(unsplash.APIKeyName)
+			switch {
+			case err == nil:
+				cfg.UnsplashAPIKey = apiKey
+			case !errors.Is(err, secrets.ErrNotFound):
+				return nil, fmt.Errorf("cannot read %s from the secret store: %v", unsplash.APIKeyName, err)
+			}
+		}
+		if err := cfg.Require(unsplash.APIKeyName); err != nil {
+			return nil, err
+		}
+		return unsplash.Provider{APIKey: cfg.UnsplashAPIKey}, nil
+	case "dir", "zip", "urls", "json":
+		if err := cfg.Require("BINGO_SOURCE"); err != nil {
+			return nil, fmt.Errorf("%v, or pass -source", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown provider %q", cfg.Provider)
+	}
+
+	switch cfg.Provider {
+	case "dir":
+		return provider.NewDir(cfg.Source)
+	case "zip":
+		return provider.NewZip(cfg.Source)
+	case "urls":
+		return provider.LoadURLList(cfg.Source)
+	default:
+		return provider.NewJSONAPI(cfg.Source, field), nil
+	}
+}
+
 func main() {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Fatalf("Error loading .env file: %v", err)
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Error loading configuration: %v", err)
 	}
 
+	imgDir := flag.String("dir", cfg.ImageDir, "directory to save images to")
+	size := flag.Int("size", cfg.ImageSize, "edge length in pixels of the square images to download")
+	kind := flag.String("provider", cfg.Provider, "image source: unsplash, dir, zip, urls or json")
+	source := flag.String("source", cfg.Source, "directory, zip file, URL list file or JSON endpoint for the provider")
+	field := flag.String("field", "url", "name of the URL field in JSON API responses")
 	// We need at least 24 images for the bingo cards
-	requiredImages := 24
-	fetchedImages := 0
-
-	fmt.Printf("Fetching %d images from Unsplash...\n", requiredImages)
+	count := flag.Int("count", 24, "number of images to fetch")
+	workers := flag.Int("workers", 4, "maximum number of concurrent downloads")
+	timeout := flag.Duration("timeout", 30*time.Second, "time limit for each request")
+	retries := flag.Int("retries", 5, "number of failed requests to tolerate before giving up")
+	debug := flag.Bool("debug", cfg.Debug, "log API requests and responses (credentials are redacted)")
+	flag.Parse()
+	cfg.Provider, cfg.Source = *kind, *source
+	logging.SetDebug(*debug)
+	unsplash.SetImageSize(*size)
 
-	for fetchedImages < requiredImages {
-		if !unsplash.CanMakeRequest() {
-			fmt.Println("Reached API request limit. Waiting for 1 hour...")
-			time.Sleep(time.Hour)
-			continue
-		}
+	p, err := newProvider(cfg, *field)
+	if err != nil {
+		log.Fatalf("Error creating provider: %v", err)
+	}
+	if c, ok := p.(io.Closer); ok {
+		defer c.Close()
+	}
 
-		// Fetch a photo from Unsplash
-		photoData, err := unsplash.GetPhoto()
-		if err != nil {
-			fmt.Printf("Error fetching photo: %v\n", err)
-			continue
-		}
+	// Cancel outstanding requests on Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-		// Save the photo
-		if err := unsplash.SavePhoto(photoData); err != nil {
-			fmt.Printf("Error saving photo: %v\n", err)
-			continue
-		}
+	fmt.Printf("Fetching %d images from %s...\n", *count, p.Name())
 
-		fetchedImages++
-		fmt.Printf("Fetched and saved image %d of %d\n", fetchedImages, requiredImages)
+	summary := provider.Fetch(ctx, p, provider.FetchOptions{
+		Count:   *count,
+		Workers: *workers,
+		Timeout: *timeout,
+		Retries: *retries,
+		Save: func(photo *provider.Photo) error {
+			_, err := provider.Save(*imgDir, photo)
+			return err
+		},
+		Progress: func(photo *provider.Photo, err error) {
+			if errors.Is(err, io.EOF) || errors.Is(err, provider.ErrLimit) {
+				return
+			}
+			if errors.Is(err, provider.ErrDuplicate) {
+				fmt.Printf("Skipped %s, already saved\n", photo.ID)
+				return
+			}
+			if err != nil {
+				logger.Warn("fetch failed", "err", err)
+				return
+			}
+			fmt.Printf("Fetched and saved %s\n", photo.ID)
+		},
+	})
 
-		// Sleep for a bit to avoid hitting rate limits
-		time.Sleep(time.Second)
+	// Print a summary of the run
+	fmt.Printf("\nSaved %d of %d images\n", len(summary.Saved), *count)
+	if len(summary.Skipped) > 0 {
+		fmt.Printf("Skipped %d images already in %s\n", len(summary.Skipped), *imgDir)
+	}
+	if len(summary.Failed) > 0 {
+		fmt.Printf("%d requests failed:\n", len(summary.Failed))
+		for _, f := range summary.Failed {
+			fmt.Printf("  %s: %s\n", f.Source, logging.Redact(f.Err.Error()))
+		}
+	}
+	if summary.Exhausted {
+		fmt.Printf("Provider %s has no more images\n", p.Name())
+	}
+	if summary.Limited {
+		fmt.Printf("Stopped at the %s request limit: %s\n", p.Name(), summary.Err)
+	} else if summary.Err != nil {
+		fmt.Printf("Stopped early: %s\n", logging.Redact(summary.Err.Error()))
 	}
 
+	if len(summary.Saved) < *count {
+		os.Exit(1)
+	}
 	fmt.Println("Successfully fetched all required images!")
 }
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"holidaybingo/pkg/game"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Orders for the called-items grid
+const (
+	orderCalled = "Call order"
+	orderAlpha  = "A-Z"
+)
+
+// historyEntry is a numbered, captioned thumbnail of one call. Tapping it
+// shows the image full size.
+type historyEntry struct {
+	widget.BaseWidget
+	call game.Call
+	size float32
+}
+
+// newHistoryEntry creates an entry with a thumbnail of the given size
+func newHistoryEntry(call game.Call, size float32) *historyEntry {
+	h := &historyEntry{call: call, size: size}
+	h.ExtendBaseWidget(h)
+	return h
+}
+
+// CreateRenderer shows the thumbnail above its number and caption
+func (h *historyEntry) CreateRenderer() fyne.WidgetRenderer {
+	image := canvas.NewImageFromResource(resourceFor(h.call.Item))
+	image.FillMode = canvas.ImageFillContain
+	image.SetMinSize(fyne.NewSize(h.size, h.size))
+
+	label := widget.NewLabel(callTitle(h.call))
+	label.Alignment = fyne.TextAlignCenter
+	label.Truncation = fyne.TextTruncateEllipsis
+
+	return widget.NewSimpleRenderer(container.NewVBox(image, label))
+}
+
+// Tapped shows the call full size
+func (h *historyEntry) Tapped(*fyne.PointEvent) {
+	showCall(h.call)
+}
+
+// callTitle returns the number and caption of a call
+func callTitle(call game.Call) string {
+	caption := call.Item.Caption
+	if caption == "" {
+		caption = call.Item.ID
+	}
+	if call.Corrected {
+		return fmt.Sprintf("%d. %s (corrected)", call.Number, caption)
+	}
+	return fmt.Sprintf("%d. %s", call.Number, caption)
+}
+
+// showCall shows a called image full size, for checking a disputed claim
+func showCall(call game.Call) {
+	image := canvas.NewImageFromResource(resourceFor(call.Item))
+	image.FillMode = canvas.ImageFillContain
+	image.SetMinSize(fyne.NewSize(600, 600))
+
+	info := widget.NewLabel(fmt.Sprintf("Called at %s", call.Time.Format("15:04:05")))
+	info.Alignment = fyne.TextAlignCenter
+
+	content := container.NewBorder(nil, info, nil, nil, image)
+	dialog.ShowCustom(callTitle(call), "Close", content, mainWindow)
+}
+
+// sortCalls returns the calls in the given order
+func sortCalls(calls []game.Call, order string) []game.Call {
+	sorted := append([]game.Call(nil), calls...)
+	if order == orderAlpha {
+		sort.SliceStable(sorted, func(i, j int) bool {
+			a, b := strings.ToLower(sorted[i].Item.Caption), strings.ToLower(sorted[j].Item.Caption)
+			if a != b {
+				return a < b
+			}
+			return sorted[i].Number < sorted[j].Number
+		})
+	}
+	return sorted
+}
+
+// showCalledGrid opens a grid of everything called so far, sorted by call
+// order or alphabetically, for checking claims at a glance
+func showCalledGrid() {
+	calls := engine.Snapshot().Calls
+	if len(calls) == 0 {
+		dialog.ShowInformation("Called Items", "Nothing has been called yet.", mainWindow)
+		return
+	}
+
+	grid := container.NewGridWrap(fyne.NewSize(140, 180))
+	fill := func(order string) {
+		objects := make([]fyne.CanvasObject, 0, len(calls))
+		for _, call := range sortCalls(calls, order) {
+			objects = append(objects, newHistoryEntry(call, 130))
+		}
+		grid.Objects = objects
+		grid.Refresh()
+	}
+
+	order := widget.NewRadioGroup([]string{orderCalled, orderAlpha}, func(order string) {
+		if order != "" {
+			fill(order)
+		}
+	})
+	order.Horizontal = true
+	order.SetSelected(orderCalled)
+
+	scroll := container.NewVScroll(grid)
+	scroll.SetMinSize(fyne.NewSize(760, 520))
+	content := container.NewBorder(order, nil, nil, nil, scroll)
+	dialog.ShowCustom(fmt.Sprintf("Called Items (%d)", len(calls)), "Close", content, mainWindow)
+}
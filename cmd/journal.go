@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"holidaybingo/pkg/game"
+
+	"fyne.io/fyne/v2/dialog"
+)
+
+// checkResume offers to resume a game left unfinished by a crash, then
+// starts journalling the session
+func checkResume() {
+	path := cfg.JournalFile
+	r, err := game.ReadJournal(path)
+	switch {
+	case errors.Is(err, game.ErrNoGame):
+		openJournal(false)
+		return
+	case err != nil:
+		// Keep the damaged journal for inspection rather than overwriting it
+		logger.Error("cannot read game journal", "path", path, "err", err)
+		if err := os.Rename(path, path+".bad"); err != nil {
+			logger.Error("cannot set aside game journal", "path", path, "err", err)
+		}
+		dialog.ShowError(fmt.Errorf("the saved game could not be read: %v", err), mainWindow)
+		openJournal(false)
+		return
+	}
+
+	calls := len(r.Calls)
+	message := fmt.Sprintf("An unfinished game was found: round %d, %d calls, last saved at %s.\n\nResume it?",
+		r.Round, calls, r.Time.Local().Format("15:04"))
+	dialog.ShowConfirm("Resume Game", message, func(resume bool) {
+		openJournal(resume)
+		if resume {
+			resumeGame(r)
+		} else {
+			logger.Info("unfinished game discarded", "round", r.Round, "calls", calls)
+		}
+	}, mainWindow)
+}
+
+// openJournal starts saving the game after every action. With resume the
+// existing journal is continued; otherwise it is started afresh.
+func openJournal(resume bool) {
+	j, err := game.OpenJournal(cfg.JournalFile, resume)
+	if err != nil {
+		logger.Error("game will not be saved", "err", err)
+		dialog.ShowError(fmt.Errorf("the game cannot be saved, so it cannot be resumed after a crash: %v", err), mainWindow)
+		return
+	}
+	engine.SetJournal(j)
+}
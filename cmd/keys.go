@@ -0,0 +1,134 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+)
+
+// Actions that can be bound to keys
+const (
+	actionNext            = "next"
+	actionBingo           = "bingo"
+	actionContinue        = "continue"
+	actionPause           = "pause" // Bingo while running, Continue while paused
+	actionVerify          = "verify"
+	actionNewRound        = "new_round"
+	actionAutoCall        = "auto_call"
+	actionUndo            = "undo"
+	actionRedo            = "redo"
+	actionFullScreen      = "full_screen"
+	actionLeaveFullScreen = "leave_full_screen"
+)
+
+// defaultKeys are the shortcuts used for any action not set in the
+// config. Presentation clickers send page down and page up, which Fyne
+// names Next and Prior.
+var defaultKeys = map[string][]string{
+	actionNext:            {string(fyne.KeySpace), string(fyne.KeyRight), string(fyne.KeyPageDown)},
+	actionBingo:           {string(fyne.KeyB)},
+	actionContinue:        {string(fyne.KeyC)},
+	actionPause:           {string(fyne.KeyPageUp)},
+	actionVerify:          {string(fyne.KeyV)},
+	actionNewRound:        {string(fyne.KeyN)},
+	actionAutoCall:        {string(fyne.KeyA)},
+	actionUndo:            {string(fyne.KeyBackspace)},
+	actionRedo:            {string(fyne.KeyR)},
+	actionFullScreen:      {string(fyne.KeyF11)},
+	actionLeaveFullScreen: {string(fyne.KeyEscape)},
+}
+
+// keyAliases are key names accepted in the config besides Fyne's own
+var keyAliases = map[string]fyne.KeyName{
+	"pagedown": fyne.KeyPageDown,
+	"pageup":   fyne.KeyPageUp,
+}
+
+// keyActions maps each bound key to the action it runs
+var keyActions map[fyne.KeyName]string
+
+// bindKeys builds keyActions from the defaults and the configured overrides.
+// Unknown actions are logged and ignored; an action bound to no keys is
+// disabled.
+func bindKeys(overrides map[string][]string) {
+	bindings := make(map[string][]string, len(defaultKeys))
+	for action, keys := range defaultKeys {
+		bindings[action] = keys
+	}
+	for action, keys := range overrides {
+		action = strings.ToLower(action)
+		if _, ok := defaultKeys[action]; !ok {
+			logger.Warn("ignoring key binding for unknown action", "action", action)
+			continue
+		}
+		bindings[action] = keys
+	}
+
+	// Bind in a fixed order so a key listed for two actions always goes to the same one
+	actions := make([]string, 0, len(bindings))
+	for action := range bindings {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+
+	keyActions = make(map[fyne.KeyName]string)
+	for _, action := range actions {
+		for _, key := range bindings[action] {
+			name := fyne.KeyName(key)
+			if alias, ok := keyAliases[strings.ToLower(key)]; ok {
+				name = alias
+			} else if len(key) == 1 {
+				// Fyne names letter keys in upper case
+				name = fyne.KeyName(strings.ToUpper(key))
+			}
+			if other, ok := keyActions[name]; ok {
+				logger.Warn("key bound twice", "key", key, "action", other, "ignored", action)
+				continue
+			}
+			keyActions[name] = action
+		}
+	}
+}
+
+// handleKeys makes the shortcuts work while w has focus
+func handleKeys(w fyne.Window) {
+	w.Canvas().SetOnTypedKey(func(ev *fyne.KeyEvent) {
+		action, ok := keyActions[ev.Name]
+		if !ok {
+			return
+		}
+		logger.Debug("shortcut", "key", ev.Name, "action", action)
+		runAction(action, w)
+	})
+}
+
+// runAction performs a bound action; w is the window that received the key
+func runAction(action string, w fyne.Window) {
+	switch action {
+	case actionNext:
+		callNext()
+	case actionBingo:
+		callBingo()
+	case actionContinue:
+		continueGame()
+	case actionPause:
+		if !callBingo() {
+			continueGame()
+		}
+	case actionVerify:
+		verifyBingo()
+	case actionNewRound:
+		nextRound()
+	case actionAutoCall:
+		engine.SetAutoCall(!engine.Snapshot().AutoCall)
+	case actionUndo:
+		undoLast()
+	case actionRedo:
+		redoLast()
+	case actionFullScreen:
+		w.SetFullScreen(!w.FullScreen())
+	case actionLeaveFullScreen:
+		w.SetFullScreen(false)
+	}
+}
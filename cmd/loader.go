@@ -0,0 +1,374 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"holidaybingo/pkg/catalog"
+	"holidaybingo/pkg/game"
+	"holidaybingo/pkg/imgproc"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// minReadyImages is how many images must be prepared before the first call
+const minReadyImages = 5
+
+var (
+	deckMu     sync.Mutex // Guards resources and deckGen
+	deckGen    int        // Incremented for every new game, to discard stale loads
+	loadCancel context.CancelFunc
+)
+
+// loadResult is the outcome of preparing one image
+type loadResult struct {
+	path string
+	res  fyne.Resource
+	err  error
+}
+
+// listImages returns the image files in dir
+func listImages(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			// Only include image files; hidden files include partial downloads
+			if imgproc.IsImageFile(entry.Name()) {
+				paths = append(paths, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	return paths, nil
+}
+
+// loadImages prepares paths on a bounded pool of workers. Results arrive
+// in completion order and the channel is closed once every image has been
+// handled or ctx is cancelled.
+func loadImages(ctx context.Context, paths []string) <-chan loadResult {
+	jobs := make(chan string)
+	results := make(chan loadResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				r := loadResult{path: path}
+				data, err := optimizeImage(path)
+				if err != nil {
+					r.err = err
+				} else {
+					r.res = fyne.NewStaticResource(filepath.Base(path), data)
+				}
+				select {
+				case results <- r:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, path := range paths {
+			select {
+			case jobs <- path:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
+
+// stopLoading cancels any image loading still running for the previous game
+func stopLoading() {
+	if loadCancel != nil {
+		loadCancel()
+		loadCancel = nil
+	}
+	deckMu.Lock()
+	deckGen++
+	deckMu.Unlock()
+}
+
+// useCatalog loads the image catalog of dir, for captions and crops. A
+// catalog that cannot be read is ignored.
+func useCatalog(dir string) {
+	cat, err := catalog.Load(dir)
+	if err != nil {
+		logger.Warn("ignoring image catalog", "dir", dir, "err", err)
+		cat = nil
+	}
+	imageCatalog = cat
+}
+
+// shuffle puts paths in random order with a Fisher-Yates shuffle
+func shuffle(paths []string, seed int64) {
+	rng := rand.New(rand.NewSource(seed))
+	for i := len(paths) - 1; i > 0; i-- {
+		j := rng.Intn(i + 1)
+		paths[i], paths[j] = paths[j], paths[i]
+	}
+}
+
+// startNewGame shuffles the image directory and loads it in the background.
+// Calling starts as soon as minReadyImages are ready; the rest of the deck
+// is added while the game is running.
+func startNewGame() {
+	stopLoading()
+	engine.End()
+
+	// Load images from the configured image directory
+	imgDir := cfg.ImageDir
+	useCatalog(imgDir)
+
+	paths, err := listImages(imgDir)
+	if err != nil {
+		logger.Error("failed to read image directory", "dir", imgDir, "err", err)
+		dialog.ShowError(fmt.Errorf("failed to read image directory: %v", err), mainWindow)
+		return
+	}
+	if len(paths) == 0 {
+		logger.Warn("no images found", "dir", imgDir)
+		dialog.ShowInformation("No images", fmt.Sprintf("No images found in %s", imgDir), mainWindow)
+		return
+	}
+
+	// Images join the deck in the order they finish loading, which keeps
+	// the shuffled order random; the journal records the resulting deck
+	// order, not just the seed.
+	seed := time.Now().UnixNano()
+	shuffle(paths, seed)
+
+	deckMu.Lock()
+	resources = make(map[string]fyne.Resource, len(paths))
+	gen := deckGen
+	deckMu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	loadCancel = cancel
+
+	status := widget.NewLabel("Preparing images...")
+	progress := widget.NewProgressBar()
+	progress.Max = float64(len(paths))
+	progressDialog := dialog.NewCustomWithoutButtons("Loading images", container.NewVBox(status, progress), mainWindow)
+	progressDialog.Show()
+
+	go func() {
+		var failed []string
+		var pending []game.Item // Ready before the game starts
+		done, ready := 0, 0
+		started := false
+
+		for r := range loadImages(ctx, paths) {
+			done++
+			if r.err != nil {
+				logger.Warn("skipping broken image", "path", r.path, "err", r.err)
+				failed = append(failed, fmt.Sprintf("%s: %v", filepath.Base(r.path), r.err))
+			} else {
+				deckMu.Lock()
+				if deckGen != gen {
+					deckMu.Unlock()
+					break
+				}
+				item := game.Item{
+					ID:      filepath.Base(r.path),
+					Caption: imageCatalog.Caption(filepath.Base(r.path)),
+					Path:    r.path,
+				}
+				resources[item.ID] = r.res
+				ready = len(resources)
+				deckMu.Unlock()
+
+				if started {
+					engine.AddItems(item)
+				} else {
+					pending = append(pending, item)
+				}
+			}
+
+			progress.SetValue(float64(done))
+			status.SetText(fmt.Sprintf("Loaded %d of %d images", done, len(paths)))
+
+			// Start calling once enough of the deck is ready
+			if !started && ready > 0 && (ready >= minReadyImages || done == len(paths)) {
+				started = true
+				progressDialog.Hide()
+				mainLabel.SetText("Let's Play!")
+				engine.NewGame(seed, pending, done < len(paths))
+				callNext()
+				logger.Info("new game started", "ready", ready, "total", len(paths))
+			}
+		}
+
+		deckMu.Lock()
+		stale := deckGen != gen
+		deckMu.Unlock()
+		if !started {
+			progressDialog.Hide()
+		}
+		if stale {
+			return
+		}
+		if started {
+			engine.FinishLoading()
+		}
+
+		logger.Info("images loaded", "ready", ready, "failed", len(failed))
+		if ready == 0 {
+			dialog.ShowInformation("No images", fmt.Sprintf("None of the images in %s could be loaded", imgDir), mainWindow)
+		}
+		if len(failed) > 0 {
+			showSkippedImages(failed)
+		}
+	}()
+}
+
+// showSkippedImages lists the images that could not be loaded
+func showSkippedImages(failed []string) {
+	list := widget.NewLabel(strings.Join(failed, "\n"))
+	scroll := container.NewVScroll(list)
+	scroll.SetMinSize(fyne.NewSize(400, 200))
+	dialog.ShowCustom(fmt.Sprintf("%d images were skipped", len(failed)), "OK", scroll, mainWindow)
+}
+
+// resumeGame reloads the images of a game read from the journal and
+// restores it in the engine. A game saved while its deck was loading then
+// gets the rest of the image directory, after the journaled deck.
+func resumeGame(r *game.Record) {
+	stopLoading()
+
+	paths := make([]string, len(r.Deck))
+	for i, item := range r.Deck {
+		paths[i] = item.Path
+	}
+	ids := make(map[string]string, len(r.Deck))
+	for _, item := range r.Deck {
+		ids[item.Path] = item.ID
+	}
+
+	var rest []string
+	if r.Loading {
+		useCatalog(cfg.ImageDir)
+		all, err := listImages(cfg.ImageDir)
+		if err != nil {
+			logger.Warn("cannot list the rest of the deck", "dir", cfg.ImageDir, "err", err)
+		}
+		for _, path := range all {
+			if _, ok := ids[path]; !ok {
+				rest = append(rest, path)
+			}
+		}
+		shuffle(rest, r.Seed)
+	}
+
+	deckMu.Lock()
+	resources = make(map[string]fyne.Resource, len(paths))
+	gen := deckGen
+	deckMu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	loadCancel = cancel
+
+	progress := widget.NewProgressBar()
+	progress.Max = float64(len(paths))
+	progressDialog := dialog.NewCustomWithoutButtons("Resuming game", container.NewVBox(widget.NewLabel("Reloading images..."), progress), mainWindow)
+	progressDialog.Show()
+
+	go func() {
+		var failed []string
+		done := 0
+		for res := range loadImages(ctx, paths) {
+			done++
+			progress.SetValue(float64(done))
+			if res.err != nil {
+				logger.Warn("image of resumed game not loaded", "path", res.path, "err", res.err)
+				failed = append(failed, fmt.Sprintf("%s: %v", filepath.Base(res.path), res.err))
+				continue
+			}
+			deckMu.Lock()
+			if deckGen == gen {
+				resources[ids[res.path]] = res.res
+			}
+			deckMu.Unlock()
+		}
+		progressDialog.Hide()
+
+		deckMu.Lock()
+		stale := deckGen != gen
+		deckMu.Unlock()
+		if stale {
+			return
+		}
+
+		engine.Restore(r)
+		logger.Info("game resumed", "round", r.Round, "calls", len(r.Calls), "failed", len(failed))
+		if len(failed) > 0 {
+			showSkippedImages(failed)
+		}
+		if r.Loading {
+			loadRest(ctx, gen, rest)
+		}
+	}()
+}
+
+// loadRest adds paths to the deck of a resumed game as they load, then
+// marks the deck complete
+func loadRest(ctx context.Context, gen int, paths []string) {
+	var failed []string
+	for res := range loadImages(ctx, paths) {
+		if res.err != nil {
+			logger.Warn("skipping broken image", "path", res.path, "err", res.err)
+			failed = append(failed, fmt.Sprintf("%s: %v", filepath.Base(res.path), res.err))
+			continue
+		}
+		deckMu.Lock()
+		if deckGen != gen {
+			deckMu.Unlock()
+			return
+		}
+		item := game.Item{
+			ID:      filepath.Base(res.path),
+			Caption: imageCatalog.Caption(filepath.Base(res.path)),
+			Path:    res.path,
+		}
+		resources[item.ID] = res.res
+		deckMu.Unlock()
+		engine.AddItems(item)
+	}
+
+	deckMu.Lock()
+	stale := deckGen != gen
+	deckMu.Unlock()
+	if stale {
+		return
+	}
+	engine.FinishLoading()
+	logger.Info("rest of resumed deck loaded", "added", len(paths)-len(failed), "failed", len(failed))
+	if len(failed) > 0 {
+		showSkippedImages(failed)
+	}
+}
@@ -1,54 +1,82 @@
 package main
 
 import (
-	"bytes"
-	"image"
-	"image/jpeg"
-	"image/png"
-	_ "image/png"
-	"log"
-	"math/rand"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
-	"path/filepath"
+	"strings"
 	"time"
 
+	"holidaybingo/pkg/catalog"
+	"holidaybingo/pkg/config"
+	"holidaybingo/pkg/game"
+	"holidaybingo/pkg/imgcache"
+	"holidaybingo/pkg/imgproc"
+	"holidaybingo/pkg/logging"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
-	"github.com/joho/godotenv"
-	"github.com/nfnt/resize"
 )
 
 var (
-	mainLabel       *widget.Label
-	historyShelf    *fyne.Container
-	images          []fyne.Resource
-	currentIndex    int
-	gameActive      bool
-	newGameButton   *widget.Button
-	nextButton      *widget.Button
-	bingoButton     *widget.Button
-	continueButton  *widget.Button
-	endGameButton   *widget.Button
-	mainView        *fyne.Container
-	buttonContainer *fyne.Container
-	historyScroll   *container.Scroll
-	imageContainer  *fyne.Container
+	mainLabel      *widget.Label
+	historyShelf   *fyne.Container
+	engine         *game.Engine
+	resources      map[string]fyne.Resource // Screen-sized images by item ID, guarded by deckMu
+	imageCatalog   *catalog.Catalog
+	shownCall      game.Call   // The call in the main image
+	shownEvent     time.Time   // Time of the last game log entry reported in the main label
+	historyCalls   []game.Call // The calls on the history shelf
+	nextButton     *widget.Button
+	bingoButton    *widget.Button
+	autoCheck      *widget.Check
+	patternSelect  *widget.Select
+	undoButton     *widget.Button
+	verifyButton   *widget.Button
+	autoDaubCheck  *widget.Check
+	claimsBox      *fyne.Container
+	redoButton     *widget.Button
+	mainView       *fyne.Container
+	historyScroll  *container.Scroll
+	imageContainer *fyne.Container
+	cfg            *config.Config
+	imageCache     *imgcache.Cache
+	mainWindow     fyne.Window
 )
 
+var logger = logging.New("caller")
+
 func main() {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Fatalf("Error loading .env file: %v", err)
+	// The caller needs no API keys, so a missing .env is not an error
+	var err error
+	cfg, err = config.Load()
+	if err != nil {
+		logger.Error("failed to load configuration, using defaults", "err", err)
+		defaults := config.Defaults()
+		cfg = &defaults
+	}
+
+	debug := flag.Bool("debug", cfg.Debug, "enable debug logging")
+	flag.Parse()
+	logging.SetDebug(*debug)
+
+	// Optimized images are cached between games and runs; without the
+	// cache every image is simply resized on each New Game
+	if imageCache, err = imgcache.New(cfg.CacheDir); err != nil {
+		logger.Warn("image cache disabled", "err", err)
 	}
 
 	myApp := app.NewWithID("com.example.holidaybingo")
 	myApp.Settings().SetTheme(theme.LightTheme())
 	myWindow := myApp.NewWindow("SSO&O Holiday BINGO!")
+	mainWindow = myWindow
 
 	// Initialize game state
 	initializeGame()
@@ -60,69 +88,91 @@ func main() {
 
 	// Create buttons
 	nextButton = widget.NewButton("Next", func() {
-		if nextButton.Text == "Continue" {
-			// Resume the game
-			gameActive = true
-			nextButton.SetText("Next")
-			bingoButton.SetText("Bingo!")  // Reset Bingo button text too
-			log.Println("Game continued")
+		if continueGame() {
 			return
 		}
-		
-		if !gameActive {
-			return
-		}
-		displayNextImage()
-		log.Println("Next clicked")
+		callNext()
+		logger.Debug("next clicked")
 	})
 
 	bingoButton = widget.NewButton("Bingo!", func() {
-		if bingoButton.Text == "End Game" {
+		if engine.Snapshot().State == game.Paused {
 			// End the game
-			gameActive = false
-			currentIndex = 0
-			historyShelf.Objects = []fyne.CanvasObject{}
-			historyScroll.Refresh()
-			imageContainer.Objects = []fyne.CanvasObject{widget.NewLabel("Click New Game to start!")}
-			bingoButton.SetText("Bingo!")
-			nextButton.SetText("Next")
-			mainView.Refresh()
+			stopLoading()
+			engine.End()
+			logger.Info("game ended")
 			return
 		}
-		
-		// Pause the game for Bingo verification
-		gameActive = false
-		nextButton.SetText("Continue")
-		bingoButton.SetText("End Game")
-		log.Println("Bingo clicked")
+		callBingo()
+	})
+
+	// The pattern that wins the round
+	patternNames := make([]string, len(game.Patterns))
+	for i, p := range game.Patterns {
+		patternNames[i] = p.String()
+	}
+	patternSelect = widget.NewSelect(patternNames, func(name string) {
+		for _, p := range game.Patterns {
+			if p.String() == name {
+				engine.SetPattern(p)
+			}
+		}
 	})
+	patternSelect.SetSelected(game.PatternLine.String())
 
 	// Left Sidebar
+	verifyButton = widget.NewButton("Verify Bingo", func() {
+		verifyBingo()
+	})
+	autoDaubCheck = widget.NewCheck("Auto-daub cards", func(on bool) {
+		if on == engine.Snapshot().AutoDaub {
+			return
+		}
+		engine.SetAutoDaub(on)
+		logger.Info("auto-daub", "on", on)
+	})
+	autoDaubCheck.SetChecked(cfg.AutoDaub)
+	claimsBox = container.NewVBox()
+	projectorFullButton = widget.NewButton("Projector Full Screen", toggleProjectorFullScreen)
+	projectorFullButton.Disable()
+
 	sidebar := container.NewVBox(
 		widget.NewLabel("SSO&O"),
 		widget.NewButton("New Game", func() {
 			startNewGame()
-			mainLabel.SetText("Let's Play!")
+		}),
+		widget.NewLabel("Pattern"),
+		patternSelect,
+		autoDaubCheck,
+		widget.NewButton("Projector", func() {
+			showProjector(myApp)
+		}),
+		projectorFullButton,
+		widget.NewButton("Web Board", func() {
+			startWebBoard()
 		}),
 		widget.NewButton("Generate Cards", func() {
 			// TODO: Implement Generate Cards functionality
-			log.Println("Generate Cards clicked")
+			logger.Debug("generate cards clicked")
 		}),
-		widget.NewButton("Verify Bingo", func() {
-			// TODO: Implement Verify Bingo functionality
-			log.Println("Verify Bingo clicked")
+		verifyButton,
+		claimsBox,
+		widget.NewButton("Caller Sheets", func() {
+			printSheets()
+		}),
+		widget.NewButton("Export Log", func() {
+			exportLog()
 		}),
 		widget.NewButton("Scoreboard", func() {
 			// TODO: Implement Scoreboard functionality
-			log.Println("Scoreboard clicked")
+			logger.Debug("scoreboard clicked")
 		}),
 		widget.NewButton("Next Round", func() {
-			// TODO: Implement Next Round functionality
-			log.Println("Next Round clicked")
+			nextRound()
 		}),
 		widget.NewButton("Config", func() {
 			// TODO: Implement Config functionality
-			log.Println("Config clicked")
+			logger.Debug("config clicked")
 		}),
 		widget.NewButton("Exit", func() {
 			myApp.Quit()
@@ -137,8 +187,12 @@ func main() {
 	historyShelf = container.NewHBox()
 	historyScroll = container.NewHScroll(historyShelf)
 
+	gridButton := widget.NewButton("Show All", func() {
+		showCalledGrid()
+	})
+
 	historyContainer := container.NewVBox(
-		historyLabel,
+		container.NewBorder(nil, nil, nil, gridButton, historyLabel),
 		container.NewPadded(historyScroll),
 	)
 
@@ -148,22 +202,44 @@ func main() {
 	// Main display area
 	imageContainer = container.NewCenter(widget.NewLabel("Click New Game to start!"))
 
+	// Auto-call mode calls the next item when the countdown runs out
+	autoCheck = widget.NewCheck("Auto-call", func(on bool) {
+		if on == engine.Snapshot().AutoCall {
+			return
+		}
+		engine.SetAutoCall(on)
+		logger.Info("auto-call", "on", on)
+	})
+
+	undoButton = widget.NewButtonWithIcon("Undo", theme.ContentUndoIcon(), func() {
+		undoLast()
+	})
+	undoButton.Disable()
+	redoButton = widget.NewButtonWithIcon("Redo", theme.ContentRedoIcon(), func() {
+		redoLast()
+	})
+	redoButton.Disable()
+
 	// Buttons below main display
 	buttonBox := container.NewHBox(
+		undoButton,
+		redoButton,
 		layout.NewSpacer(),
 		nextButton,
 		layout.NewSpacer(),
 		bingoButton,
 		layout.NewSpacer(),
+		autoCheck,
+		newCountdown(),
 	)
 
 	// Right side layout (history at top, main content below)
 	rightSide := container.NewBorder(
-		historyContainer, // top
-		buttonBox,        // bottom
-		nil,              // left
-		nil,              // right
-		imageContainer,   // center
+		historyContainer,                        // top
+		container.NewVBox(mainLabel, buttonBox), // bottom
+		nil,                                     // left
+		nil,                                     // right
+		imageContainer,                          // center
 	)
 
 	// Combine left sidebar with right side content
@@ -174,153 +250,261 @@ func main() {
 	content.SetOffset(0.2) // Sidebar takes 20% of horizontal space
 
 	mainView = container.NewMax(content)
+	engine.Subscribe(func(s game.Snapshot) {
+		updateCountdown(s)
+		updateCallerView(s)
+		updateProjector(s)
+	})
+	bindKeys(cfg.Keys)
+	handleKeys(myWindow)
 	myWindow.SetContent(mainView)
 	myWindow.Resize(fyne.NewSize(1024, 768))
-	myWindow.ShowAndRun()
+	myWindow.Show()
+	checkResume()
+	myApp.Run()
 }
 
 func initializeGame() {
 	// Initialize resources and game state
-	images = make([]fyne.Resource, 0)
-	currentIndex = 0
-	gameActive = false
-	log.Println("Game initialized")
+	engine = game.NewEngine()
+	engine.SetAutoInterval(time.Duration(cfg.AutoCallSeconds) * time.Second)
+	engine.SetAutoDaub(cfg.AutoDaub)
+	resources = make(map[string]fyne.Resource)
+	logger.Debug("game initialized")
 }
 
-func startNewGame() {
-	// Clear history
-	historyShelf.Objects = []fyne.CanvasObject{}
-	historyScroll.Refresh()
-
-	// Load images from the img directory
-	imgDir := "img"
-	entries, err := os.ReadDir(imgDir)
-	if err != nil {
-		log.Printf("Failed to read image directory: %v", err)
-		return
+// optimizeImage returns the screen-sized version of the image at imgPath,
+// from the image cache when possible
+func optimizeImage(imgPath string) ([]byte, error) {
+	optimize := func(src []byte) ([]byte, error) {
+		return imgproc.Optimize(src, imgproc.ScreenSize)
 	}
-
-	// Reset images slice
-	images = make([]fyne.Resource, 0)
-
-	// Load each image file
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			// Only include image files
-			if ext := filepath.Ext(entry.Name()); ext == ".png" || ext == ".jpg" || ext == ".jpeg" {
-				imgPath := filepath.Join(imgDir, entry.Name())
-				
-				// Optimize and load the image
-				imgData, err := optimizeImage(imgPath)
-				if err != nil {
-					log.Printf("Failed to optimize image %s: %v", imgPath, err)
-					continue
-				}
-
-				// Create a static resource from the optimized image data
-				imgRes := fyne.NewStaticResource(entry.Name(), imgData)
-				images = append(images, imgRes)
-			}
+	if imageCache == nil {
+		src, err := os.ReadFile(imgPath)
+		if err != nil {
+			return nil, err
 		}
+		return optimize(src)
 	}
+	return imageCache.Get(imgPath, fmt.Sprintf("v%d-screen%d", imgproc.Version, imgproc.ScreenSize), optimize)
+}
 
-	if len(images) == 0 {
-		log.Println("No images found in img directory")
-		return
-	}
+// resourceFor returns the screen-sized image of an item
+func resourceFor(item game.Item) fyne.Resource {
+	deckMu.Lock()
+	defer deckMu.Unlock()
+	return resources[item.ID]
+}
 
-	// Fisher-Yates shuffle
-	rand.Seed(time.Now().UnixNano())
-	for i := len(images) - 1; i > 0; i-- {
-		j := rand.Intn(i + 1)
-		images[i], images[j] = images[j], images[i]
+// callNext asks the engine for the next item; the view updates when the engine notifies it
+func callNext() {
+	call, err := engine.Call()
+	switch {
+	case errors.Is(err, game.ErrLoading):
+		mainLabel.SetText("Still loading images...")
+	case err != nil:
+		logger.Debug("cannot call", "err", err)
+	default:
+		logger.Info("called", "number", call.Number, "id", call.Item.ID)
 	}
-	
-	gameActive = true
-	currentIndex = 0
-	displayNextImage()
-	log.Println("New game started with shuffled images")
 }
 
-func optimizeImage(imgPath string) ([]byte, error) {
-	// Open the image file
-	file, err := os.Open(imgPath)
-	if err != nil {
-		return nil, err
+// callBingo pauses the game while a bingo is checked. It reports whether
+// the game was running.
+func callBingo() bool {
+	if err := engine.Pause(); err != nil {
+		return false
 	}
-	defer file.Close()
+	logger.Info("bingo called, game paused")
+	return true
+}
 
-	// Decode the image
-	img, format, err := image.Decode(file)
-	if err != nil {
-		return nil, err
+// continueGame resumes a paused game. It reports whether the game was paused.
+func continueGame() bool {
+	if err := engine.Resume(); err != nil {
+		return false
 	}
+	logger.Info("game continued")
+	return true
+}
 
-	// Calculate new size (max dimension of 800 pixels while maintaining aspect ratio)
-	bounds := img.Bounds()
-	width := bounds.Dx()
-	height := bounds.Dy()
-	var newWidth, newHeight uint
-	if width > height {
-		newWidth = 800
-		newHeight = uint(float64(height) * (800.0 / float64(width)))
-	} else {
-		newHeight = 800
-		newWidth = uint(float64(width) * (800.0 / float64(height)))
+// verifyBingo pauses the game so a claimed bingo can be checked, and
+// records the host's verdict. Claims sent from digital cards are checked
+// first, oldest first.
+func verifyBingo() {
+	s := engine.Snapshot()
+	if s.State != game.Running && s.State != game.Paused {
+		return
+	}
+	callBingo()
+	if len(s.Pending) > 0 {
+		verifyCardClaim(s.Pattern, s.Pending[0], len(s.Pending)-1)
+		return
 	}
 
-	// Resize the image
-	resized := resize.Resize(newWidth, newHeight, img, resize.Lanczos3)
+	player := widget.NewEntry()
+	player.SetPlaceHolder("Name or card number")
+	items := []*widget.FormItem{widget.NewFormItem("Player", player)}
+	title := fmt.Sprintf("Verify Bingo (%s)", s.Pattern)
+	dialog.ShowForm(title, "Winner", "Not a Bingo", items, func(valid bool) {
+		name := strings.TrimSpace(player.Text)
+		if name == "" {
+			name = "Unknown player"
+		}
+		claim := engine.RecordClaim(name, valid)
+		logger.Info("claim checked", "player", claim.Player, "valid", claim.Valid, "calls", claim.Call)
+	}, mainWindow)
+}
 
-	// Encode the resized image
-	var buf bytes.Buffer
-	switch format {
-	case "jpeg":
-		err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: 85})
-	case "png":
-		err = png.Encode(&buf, resized)
-	default:
-		err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: 85})
-	}
-	if err != nil {
-		return nil, err
+// nextRound reshuffles the deck for a new round, after confirmation if a
+// game is under way
+func nextRound() {
+	s := engine.Snapshot()
+	if len(s.Calls) == 0 || s.State == game.Ended {
+		startNewGame()
+		return
 	}
-
-	return buf.Bytes(), nil
+	dialog.ShowConfirm("Next Round", "End this round and reshuffle the deck?", func(ok bool) {
+		if ok {
+			logger.Info("next round", "calls", len(s.Calls))
+			startNewGame()
+		}
+	}, mainWindow)
 }
 
-func displayNextImage() {
-	if !gameActive {
-		log.Println("Game not active")
+// undoLast reverts the last change to the game after confirmation
+func undoLast() {
+	action := engine.Snapshot().UndoAction
+	if action == "" {
 		return
 	}
+	dialog.ShowConfirm("Undo", fmt.Sprintf("Undo %s?", action), func(ok bool) {
+		if !ok {
+			return
+		}
+		event, err := engine.Undo()
+		if err != nil {
+			logger.Debug("cannot undo", "err", err)
+			return
+		}
+		logger.Info("correction", "detail", event.Detail)
+	}, mainWindow)
+}
 
-	// If we've reached the end, cycle back to the beginning
-	if currentIndex >= len(images) {
-		currentIndex = 0
+// redoLast repeats the last undone change
+func redoLast() {
+	event, err := engine.Redo()
+	if err != nil {
+		logger.Debug("cannot redo", "err", err)
+		return
 	}
+	logger.Info("correction", "detail", event.Detail)
+}
 
-	// Add current image to history before moving to next (only if not first display)
-	if currentIndex > 0 {
-		previousImage := canvas.NewImageFromResource(images[currentIndex-1])
-		previousImage.SetMinSize(fyne.NewSize(100, 100))
-		previousImage.FillMode = canvas.ImageFillContain
+// updateCallerView redraws the host window from the engine's state
+func updateCallerView(s game.Snapshot) {
+	defer showEventStatus(s)
 
-		historyFrame := container.NewMax(previousImage)
-		historyFrame.Resize(fyne.NewSize(100, 100))
+	// The timer turns itself off when the deck runs out or the game ends
+	if autoCheck.Checked != s.AutoCall {
+		autoCheck.SetChecked(s.AutoCall)
+	}
 
-		paddedFrame := container.NewPadded(historyFrame)
-		historyShelf.Add(paddedFrame)
+	switch s.State {
+	case game.Paused:
+		nextButton.SetText("Continue")
+		bingoButton.SetText("End Game")
+	default:
+		nextButton.SetText("Next")
+		bingoButton.SetText("Bingo!")
+	}
+	if s.Pattern != "" && patternSelect.Selected != s.Pattern.String() {
+		patternSelect.SetSelected(s.Pattern.String())
+	}
+	setEnabled(undoButton, s.UndoAction != "")
+	setEnabled(redoButton, s.RedoAction != "")
+	if autoDaubCheck.Checked != s.AutoDaub {
+		autoDaubCheck.SetChecked(s.AutoDaub)
+	}
+	updateClaims(s)
+
+	// History always matches the engine's call list, current item included.
+	// Most changes, such as players marking cards, leave it as it is.
+	if !sameCalls(s.Calls, historyCalls) {
+		historyCalls = s.Calls
+		history := make([]fyne.CanvasObject, len(s.Calls))
+		for i, call := range s.Calls {
+			history[i] = newHistoryEntry(call, 100)
+		}
+		historyShelf.Objects = history
+		historyShelf.Refresh()
+		historyScroll.Offset.X = historyShelf.MinSize().Width // Show the latest calls
 		historyScroll.Refresh()
 	}
 
+	current, ok := s.Current()
+	if !ok {
+		if s.State == game.Idle || s.State == game.Ended {
+			imageContainer.Objects = []fyne.CanvasObject{widget.NewLabel("Click New Game to start!")}
+		}
+		shownCall = game.Call{}
+		mainView.Refresh()
+		return
+	}
+	if current.Number == shownCall.Number && current.Time.Equal(shownCall.Time) {
+		return
+	}
+	shownCall = current
+	if current.Corrected {
+		mainLabel.SetText(fmt.Sprintf("Call #%d (corrected)", current.Number))
+	} else {
+		mainLabel.SetText(fmt.Sprintf("Call #%d", current.Number))
+	}
+
 	// Update the main image
-	image := canvas.NewImageFromResource(images[currentIndex])
+	image := canvas.NewImageFromResource(resourceFor(current.Item))
 	image.FillMode = canvas.ImageFillContain
 	image.SetMinSize(fyne.NewSize(500, 500))
 	imageContainer.Objects = []fyne.CanvasObject{image}
 
-	currentIndex++
 	mainView.Refresh()
-	log.Printf("Displayed image %d", currentIndex)
+	logger.Info("displayed image", "call", current.Number, "name", current.Item.ID)
+}
+
+// sameCalls reports whether two call lists are identical
+func sameCalls(a, b []game.Call) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// showEventStatus reports a correction or a resumed game in the main label,
+// once per event. It runs after the call is shown, so the report stays up.
+func showEventStatus(s game.Snapshot) {
+	event := s.LastEvent
+	if event.Time.Equal(shownEvent) {
+		return
+	}
+	shownEvent = event.Time
+	switch event.Kind {
+	case game.EventUndo, game.EventRedo:
+		mainLabel.SetText("Corrected: " + event.Detail)
+	case game.EventRestore:
+		mainLabel.SetText(fmt.Sprintf("Resumed round %d after %d calls - click Continue", s.Round, len(s.Calls)))
+	}
+}
+
+// setEnabled enables or disables a button
+func setEnabled(b *widget.Button, enabled bool) {
+	if enabled {
+		b.Enable()
+	} else {
+		b.Disable()
+	}
 }
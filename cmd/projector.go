@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"holidaybingo/pkg/game"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+// recentCalls is how many earlier calls the projector strip shows
+const recentCalls = 5
+
+var (
+	projectorWindow  fyne.Window
+	projectorImage   *fyne.Container
+	projectorCaption *canvas.Text
+	projectorNumber  *canvas.Text
+	projectorRecent  *fyne.Container
+
+	projectorFullButton *widget.Button // Toggles the projector window full screen
+)
+
+// showProjector opens the presenter window, or brings it to the front if it
+// is already open. Once it is on the projector's screen, projectorFullButton
+// makes it full screen.
+func showProjector(a fyne.App) {
+	if projectorWindow != nil {
+		projectorWindow.RequestFocus()
+		return
+	}
+
+	w := a.NewWindow("Holiday Bingo - Projector")
+
+	projectorNumber = canvas.NewText("", color.Gray{Y: 0x60})
+	projectorNumber.TextSize = 32
+	projectorNumber.Alignment = fyne.TextAlignCenter
+
+	projectorCaption = canvas.NewText("", color.Black)
+	projectorCaption.TextSize = 56
+	projectorCaption.TextStyle = fyne.TextStyle{Bold: true}
+	projectorCaption.Alignment = fyne.TextAlignCenter
+
+	projectorImage = container.NewMax()
+	projectorRecent = container.NewHBox()
+
+	header := container.NewVBox(projectorNumber)
+	footer := container.NewVBox(
+		projectorCaption,
+		container.NewHBox(layout.NewSpacer(), projectorRecent, layout.NewSpacer()),
+	)
+	background := canvas.NewRectangle(color.White)
+	w.SetContent(container.NewMax(background, container.NewBorder(header, footer, nil, nil, projectorImage)))
+
+	handleKeys(w)
+	w.SetOnClosed(func() {
+		projectorWindow = nil
+		projectorFullButton.Disable()
+	})
+
+	projectorWindow = w
+	w.Resize(fyne.NewSize(1280, 720))
+	w.Show()
+	projectorFullButton.Enable()
+	updateProjector(engine.Snapshot())
+}
+
+// toggleProjectorFullScreen switches the presenter window in or out of full
+// screen on the display it is on
+func toggleProjectorFullScreen() {
+	if projectorWindow == nil {
+		return
+	}
+	projectorWindow.SetFullScreen(!projectorWindow.FullScreen())
+}
+
+// updateProjector redraws the presenter window from the engine's state
+func updateProjector(s game.Snapshot) {
+	if projectorWindow == nil {
+		return
+	}
+
+	current, ok := s.Current()
+	if !ok {
+		projectorNumber.Text = ""
+		projectorCaption.Text = "Holiday BINGO!"
+		projectorImage.Objects = nil
+		projectorRecent.Objects = nil
+		projectorNumber.Refresh()
+		projectorCaption.Refresh()
+		projectorImage.Refresh()
+		projectorRecent.Refresh()
+		return
+	}
+
+	projectorNumber.Text = fmt.Sprintf("Call #%d", current.Number)
+	if current.Corrected {
+		projectorNumber.Text += " (corrected)"
+	}
+	if s.State == game.Paused {
+		projectorNumber.Text += " - checking bingo"
+	}
+	projectorCaption.Text = current.Item.Caption
+
+	image := canvas.NewImageFromResource(resourceFor(current.Item))
+	image.FillMode = canvas.ImageFillContain
+	projectorImage.Objects = []fyne.CanvasObject{image}
+
+	// Earlier calls, newest on the left
+	var recent []fyne.CanvasObject
+	for _, call := range s.Recent(recentCalls) {
+		thumb := canvas.NewImageFromResource(resourceFor(call.Item))
+		thumb.FillMode = canvas.ImageFillContain
+		thumb.SetMinSize(fyne.NewSize(120, 120))
+
+		label := canvas.NewText(fmt.Sprintf("%d. %s", call.Number, call.Item.Caption), color.Black)
+		label.TextSize = 16
+		label.Alignment = fyne.TextAlignCenter
+
+		recent = append(recent, container.NewPadded(container.NewVBox(thumb, label)))
+	}
+	projectorRecent.Objects = recent
+
+	projectorNumber.Refresh()
+	projectorCaption.Refresh()
+	projectorImage.Refresh()
+	projectorRecent.Refresh()
+}
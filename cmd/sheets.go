@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"holidaybingo/pkg/cardgen"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// printSheets saves the caller master sheet and, once calling has started,
+// the call sheet for the current round to the cards directory
+func printSheets() {
+	paths, err := listImages(cfg.ImageDir)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("failed to read image directory: %v", err), mainWindow)
+		return
+	}
+	calls := engine.Snapshot().Calls
+
+	// A sheet with a missing image is still useful, so print placeholders
+	generator := cardgen.NewGenerator("")
+	generator.SetImages(paths)
+	generator.SetCatalog(imageCatalog)
+	generator.SetCache(imageCache)
+	generator.SetMissingPolicy(cardgen.UsePlaceholder)
+
+	progress := dialog.NewCustomWithoutButtons("Caller Sheets", widget.NewProgressBarInfinite(), mainWindow)
+	progress.Show()
+	go func() {
+		var saved []string
+		var failure error
+		defer func() {
+			progress.Hide()
+			if failure != nil {
+				logger.Error("caller sheets not saved", "err", failure)
+				dialog.ShowError(failure, mainWindow)
+				return
+			}
+			dialog.ShowInformation("Caller Sheets", "Saved:\n"+strings.Join(saved, "\n"), mainWindow)
+		}()
+
+		path := filepath.Join(cfg.CardsDir, "HolidayBingo_master_sheet.pdf")
+		if _, failure = generator.SaveMasterSheet(path); failure != nil {
+			return
+		}
+		saved = append(saved, path)
+
+		if len(calls) > 0 {
+			path = filepath.Join(cfg.CardsDir, "HolidayBingo_call_sheet.pdf")
+			if _, failure = generator.SaveCallSheet(calls, path); failure != nil {
+				return
+			}
+			saved = append(saved, path)
+		}
+		logger.Info("caller sheets saved", "files", len(saved), "calls", len(calls))
+	}()
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+
+	"holidaybingo/pkg/game"
+	"holidaybingo/pkg/web"
+
+	"fyne.io/fyne/v2/dialog"
+)
+
+// webServer serves the browser caller board once the host has started it
+var webServer *web.Server
+
+// startWebBoard starts the browser caller board, if it is not already
+// running, and shows the addresses remote attendees can open
+func startWebBoard() {
+	if webServer == nil {
+		server := web.New(engine, func(item game.Item) ([]byte, bool) {
+			if res := resourceFor(item); res != nil {
+				return res.Content(), true
+			}
+			// Squares on players' cards may not be in this game's deck
+			data, err := optimizeImage(filepath.Join(cfg.ImageDir, filepath.Base(item.ID)))
+			if err != nil {
+				logger.Debug("image not served", "id", item.ID, "err", err)
+				return nil, false
+			}
+			return data, true
+		})
+		server.SetDealer(cardDealer{})
+		if err := server.Start(cfg.WebAddr); err != nil {
+			logger.Error("web board not started", "addr", cfg.WebAddr, "err", err)
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		webServer = server
+	}
+
+	urls := boardURLs(cfg.WebAddr)
+	play := make([]string, len(urls))
+	for i, u := range urls {
+		play[i] = u + "play.html"
+	}
+	dialog.ShowInformation("Web Board",
+		"Remote attendees can follow the game at:\n\n"+strings.Join(urls, "\n")+
+			"\n\nPlayers can get a digital card at:\n\n"+strings.Join(play, "\n"), mainWindow)
+}
+
+// boardURLs returns the addresses the board can be reached at from other
+// machines, or from this one if the host has no network address
+func boardURLs(addr string) []string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return []string{"http://" + addr}
+	}
+	if host != "" && host != "0.0.0.0" && host != "::" {
+		return []string{fmt.Sprintf("http://%s/", net.JoinHostPort(host, port))}
+	}
+
+	var urls []string
+	addrs, _ := net.InterfaceAddrs()
+	for _, a := range addrs {
+		ipnet, ok := a.(*net.IPNet)
+		if !ok || ipnet.IP.IsLoopback() || ipnet.IP.To4() == nil {
+			continue
+		}
+		urls = append(urls, fmt.Sprintf("http://%s/", net.JoinHostPort(ipnet.IP.String(), port)))
+	}
+	if len(urls) == 0 {
+		urls = append(urls, fmt.Sprintf("http://localhost:%s/", port))
+	}
+	return urls
+}
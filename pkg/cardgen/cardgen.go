@@ -3,32 +3,41 @@ package cardgen
 import (
 	"fmt"
 	"math/rand"
-	"time"
 	"os"
 	"path/filepath"
+	"time"
+
+	"holidaybingo/pkg/catalog"
+	"holidaybingo/pkg/imgcache"
+	"holidaybingo/pkg/imgproc"
+
 	"github.com/jung-kurt/gofpdf"
-	"image"
-	_ "image/jpeg"
-	_ "image/png"
 )
 
 // Card represents a bingo card with its properties
 type Card struct {
-	ID      string
-	Squares []string
+	ID      string   `json:"id"`
+	Squares []string `json:"squares"` // Image paths, with FREE in the centre
 }
 
 // Generator handles the card generation process
 type Generator struct {
 	templatePath string
 	images       []string
+	dpi          int
+	cache        *imgcache.Cache
+	catalog      *catalog.Catalog
+	crop         imgproc.Crop
+	policy       MissingPolicy
 }
 
 // NewGenerator creates a new card generator
 func NewGenerator(templatePath string) *Generator {
 	return &Generator{
 		templatePath: templatePath,
-		images:      make([]string, 0),
+		images:       make([]string, 0),
+		dpi:          DefaultDPI,
+		crop:         imgproc.Crop{Mode: imgproc.CropEntropy},
 	}
 }
 
@@ -37,6 +46,22 @@ func (g *Generator) SetImages(images []string) {
 	g.images = images
 }
 
+// SetCache makes the generator reuse print-sized copies of the images
+// between runs instead of converting the originals every time
+func (g *Generator) SetCache(cache *imgcache.Cache) {
+	g.cache = cache
+}
+
+// SetCatalog supplies per-image settings such as crop overrides
+func (g *Generator) SetCatalog(c *catalog.Catalog) {
+	g.catalog = c
+}
+
+// SetCrop sets how images without a crop override are cut to square tiles
+func (g *Generator) SetCrop(crop imgproc.Crop) {
+	g.crop = crop
+}
+
 // generateID creates a unique card ID in format XX123
 func generateID() string {
 	const letters = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
@@ -59,6 +84,12 @@ func (g *Generator) GenerateCards(count int) ([]Card, error) {
 	if len(g.images) < 24 { // Need at least 24 images (5x5 grid minus center)
 		return nil, fmt.Errorf("not enough images provided: need at least 24, got %d", len(g.images))
 	}
+	// A blank square on a printed card is worse than no card at all
+	if g.policy == FailOnMissing {
+		if err := g.Validate(); err != nil {
+			return nil, err
+		}
+	}
 
 	cards := make([]Card, count)
 	for i := 0; i < count; i++ {
@@ -76,7 +107,7 @@ func (g *Generator) GenerateCards(count int) ([]Card, error) {
 		// Take first 24 images for the card
 		squares := make([]string, 25)
 		copy(squares[:12], shuffled[:12])
-		squares[12] = "FREE" // Center square is FREE
+		squares[freeIndex] = "FREE" // Center square is FREE
 		copy(squares[13:], shuffled[12:24])
 
 		cards[i] = Card{
@@ -88,99 +119,149 @@ func (g *Generator) GenerateCards(count int) ([]Card, error) {
 	return cards, nil
 }
 
-// SaveToPDF saves the cards to PDF files with clickable squares
-func (g *Generator) SaveToPDF(cards []Card, outputDir string) error {
+// Card layout, in mm
+const (
+	margin     = 10.0
+	cellSize   = 35.0
+	gridSize   = 5
+	imageSize  = 30.0
+	pageWidth  = 210.0 // A4 width
+	pageHeight = 297.0 // A4 height
+	freeIndex  = 12    // Center FREE space
+)
+
+// newPDF creates an empty A4 document for cards
+func newPDF() *gofpdf.Fpdf {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetAutoPageBreak(false, 0)
+	return pdf
+}
+
+// drawCard adds a page for card to pdf using the pre-rendered tiles.
+// Squares without a tile get a placeholder, and are returned in the report.
+func (g *Generator) drawCard(pdf *gofpdf.Fpdf, card Card, tiles map[string]*tile, failed map[string]error) CardReport {
+	report := CardReport{CardID: card.ID}
+	pdf.AddPage()
+
+	// Calculate starting position to center the grid
+	startX := (pageWidth - (cellSize * float64(gridSize))) / 2
+	startY := margin + 20 // Leave space for title
+
+	// Add title
+	pdf.SetFont("Arial", "B", 24)
+	pdf.Text((pageWidth-pdf.GetStringWidth("Holiday Bingo"))/2, margin+10, "Holiday Bingo")
+
+	// Add card ID
+	pdf.SetFont("Arial", "", 12)
+	pdf.Text(margin, pageHeight-margin, fmt.Sprintf("Card ID: %s", card.ID))
+
+	// Draw grid and add images
+	for row := 0; row < gridSize; row++ {
+		for col := 0; col < gridSize; col++ {
+			x := startX + float64(col)*cellSize
+			y := startY + float64(row)*cellSize
+			index := row*gridSize + col
+
+			// Draw cell border
+			pdf.Rect(x, y, cellSize, cellSize, "D")
+
+			if index == freeIndex {
+				pdf.SetFont("Arial", "B", 16)
+				pdf.Text(x+(cellSize-pdf.GetStringWidth("FREE"))/2, y+cellSize/2, "FREE")
+			} else if t, ok := tiles[card.Squares[index]]; ok {
+				// Tiles are square, so they fill the image area exactly
+				offset := (cellSize - imageSize) / 2
+				opts := registerTile(pdf, t)
+				pdf.ImageOptions(t.name, x+offset, y+offset, imageSize, imageSize, false, opts, 0, "")
+			} else {
+				imgPath := card.Squares[index]
+				g.drawPlaceholder(pdf, x, y, imgPath)
+				report.Substitutions = append(report.Substitutions, Substitution{Square: index, Image: imgPath, Err: failed[imgPath]})
+			}
+
+			// Add checkbox
+			pdf.SetFont("ZapfDingbats", "", 12)
+			pdf.Text(x+2, y+5, "☐")
+		}
+	}
+
+	// Add instructions
+	pdf.SetFont("Arial", "", 10)
+	pdf.Text(margin, margin, "Click or mark the boxes to shade squares as they are called.")
+	return report
+}
+
+// drawPlaceholder prints the caption of an image that could not be
+// rendered, inside a dashed box, so the square can still be played
+func (g *Generator) drawPlaceholder(pdf *gofpdf.Fpdf, x, y float64, imgPath string) {
+	offset := (cellSize - imageSize) / 2
+	pdf.SetDashPattern([]float64{1, 1}, 0)
+	pdf.Rect(x+offset, y+offset, imageSize, imageSize, "D")
+	pdf.SetDashPattern([]float64{}, 0)
+
+	pdf.SetFont("Arial", "B", 10)
+	pdf.SetXY(x+offset, y+cellSize/2-5)
+	tr := pdf.UnicodeTranslatorFromDescriptor("") // Core fonts use cp1252
+	pdf.MultiCell(imageSize, 4, tr(g.catalog.Caption(imgPath)), "", "C", false)
+}
+
+// render pre-renders the images on cards, enforcing the missing image policy
+func (g *Generator) render(cards []Card) (map[string]*tile, map[string]error, error) {
+	tiles, failed := g.prerender(cards)
+	if len(failed) > 0 && g.policy == FailOnMissing {
+		return nil, nil, ImageError(failed)
+	}
+	return tiles, failed, nil
+}
+
+// SaveToPDF saves each card to its own PDF file in outputDir. Every image
+// is rendered at print resolution once, however many cards use it. The
+// report lists any placeholders printed under the UsePlaceholder policy.
+func (g *Generator) SaveToPDF(cards []Card, outputDir string) (*Report, error) {
 	// Create output directory if it doesn't exist
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %v", err)
+		return nil, fmt.Errorf("failed to create output directory: %v", err)
 	}
 
-	for _, card := range cards {
-		// Create new PDF
-		pdf := gofpdf.New("P", "mm", "A4", "")
-		pdf.SetAutoPageBreak(false, 0)
-		pdf.AddPage()
-
-		// Set up card layout
-		const (
-			margin     = 10.0  // mm
-			cellSize   = 35.0  // mm
-			gridSize   = 5
-			imageSize  = 30.0  // mm
-			pageWidth  = 210.0 // A4 width in mm
-			pageHeight = 297.0 // A4 height in mm
-		)
-
-		// Calculate starting position to center the grid
-		startX := (pageWidth - (cellSize * float64(gridSize))) / 2
-		startY := margin + 20 // Leave space for title
-
-		// Add title
-		pdf.SetFont("Arial", "B", 24)
-		pdf.Text((pageWidth-pdf.GetStringWidth("Holiday Bingo"))/2, margin+10, "Holiday Bingo")
-
-		// Add card ID
-		pdf.SetFont("Arial", "", 12)
-		pdf.Text(margin, pageHeight-margin, fmt.Sprintf("Card ID: %s", card.ID))
-
-		// Draw grid and add images
-		for row := 0; row < gridSize; row++ {
-			for col := 0; col < gridSize; col++ {
-				x := startX + float64(col)*cellSize
-				y := startY + float64(row)*cellSize
-				index := row*gridSize + col
-
-				// Draw cell border
-				pdf.Rect(x, y, cellSize, cellSize, "D")
-
-				if index == 12 { // Center FREE space
-					pdf.SetFont("Arial", "B", 16)
-					pdf.Text(x+(cellSize-pdf.GetStringWidth("FREE"))/2, y+cellSize/2, "FREE")
-					
-					// Add checkbox
-					pdf.SetFont("ZapfDingbats", "", 12)
-					pdf.Text(x+2, y+5, "☐")
-				} else {
-					// Add image
-					imgPath := card.Squares[index]
-					if imgFile, err := os.Open(imgPath); err == nil {
-						if img, _, err := image.DecodeConfig(imgFile); err == nil {
-							imgFile.Close()
-							
-							// Calculate scaling to fit in cell while maintaining aspect ratio
-							scale := imageSize / float64(img.Width)
-							if float64(img.Height)*scale > imageSize {
-								scale = imageSize / float64(img.Height)
-							}
-							
-							imgWidth := float64(img.Width) * scale
-							imgHeight := float64(img.Height) * scale
-							
-							// Center image in cell
-							imgX := x + (cellSize-imgWidth)/2
-							imgY := y + (cellSize-imgHeight)/2
-							
-							pdf.Image(imgPath, imgX, imgY, imgWidth, imgHeight, false, "", 0, "")
-						}
-					}
-					
-					// Add checkbox
-					pdf.SetFont("ZapfDingbats", "", 12)
-					pdf.Text(x+2, y+5, "☐")
-				}
-			}
-		}
+	tiles, failed, err := g.render(cards)
+	if err != nil {
+		return nil, err
+	}
 
-		// Add instructions
-		pdf.SetFont("Arial", "", 10)
-		pdf.Text(margin, margin, "Click or mark the boxes to shade squares as they are called.")
+	report := &Report{}
+	for _, card := range cards {
+		pdf := newPDF()
+		report.Cards = append(report.Cards, g.drawCard(pdf, card, tiles, failed))
 
 		// Save PDF
 		outputPath := filepath.Join(outputDir, fmt.Sprintf("HolidayBingo_%s.pdf", card.ID))
 		if err := pdf.OutputFileAndClose(outputPath); err != nil {
-			return fmt.Errorf("failed to save PDF: %v", err)
+			return nil, fmt.Errorf("failed to save PDF: %v", err)
 		}
 	}
 
-	return nil
+	return report, nil
+}
+
+// SaveCombinedPDF saves all cards to a single PDF at outputPath, one card
+// per page. Each image is embedded once and shared by every page using it.
+func (g *Generator) SaveCombinedPDF(cards []Card, outputPath string) (*Report, error) {
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %v", err)
+	}
+
+	tiles, failed, err := g.render(cards)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+	pdf := newPDF()
+	for _, card := range cards {
+		report.Cards = append(report.Cards, g.drawCard(pdf, card, tiles, failed))
+	}
+	if err := pdf.OutputFileAndClose(outputPath); err != nil {
+		return nil, fmt.Errorf("failed to save PDF: %v", err)
+	}
+	return report, nil
 }
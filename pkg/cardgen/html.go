@@ -0,0 +1,77 @@
+package cardgen
+
+import (
+	_ "embed"
+	"fmt"
+	"html"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// defaultTemplate is the HTML card used when the generator's template
+// cannot be found
+//
+//go:embed templates/card_template_working.html
+var defaultTemplate string
+
+// brandedTemplate carries the logo shown on HTML cards
+//
+//go:embed templates/card_template.html
+var brandedTemplate string
+
+var logoPattern = regexp.MustCompile(`<img src="(data:image/[^"]+)"`)
+
+// SquareHTML returns the markup for square index of an HTML card, showing
+// the image at imgPath
+type SquareHTML func(index int, imgPath string) string
+
+// RenderHTML fills the HTML card template with card. The markup for each
+// square comes from square; the free square is left as the template has it.
+func (g *Generator) RenderHTML(card Card, square SquareHTML) (string, error) {
+	if len(card.Squares) != gridSize*gridSize {
+		return "", fmt.Errorf("card %s has %d squares, want %d", card.ID, len(card.Squares), gridSize*gridSize)
+	}
+	tmpl, err := g.htmlTemplate()
+	if err != nil {
+		return "", err
+	}
+
+	pairs := []string{
+		"[[CARD_ID]]", html.EscapeString(card.ID),
+		"[[LOGO_PLACEHOLDER]]", cardLogo(),
+	}
+	for i, path := range card.Squares {
+		if i != freeIndex {
+			pairs = append(pairs, fmt.Sprintf("[[IMG_%d_%d]]", i/gridSize, i%gridSize), square(i, path))
+		}
+	}
+	return strings.NewReplacer(pairs...).Replace(tmpl), nil
+}
+
+// htmlTemplate returns the generator's HTML card template, or the built-in
+// one if the template path does not exist
+func (g *Generator) htmlTemplate() (string, error) {
+	if g.templatePath == "" {
+		return defaultTemplate, nil
+	}
+	data, err := os.ReadFile(g.templatePath)
+	if os.IsNotExist(err) {
+		return defaultTemplate, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading card template: %v", err)
+	}
+	if !strings.Contains(string(data), "[[IMG_0_0]]") {
+		return "", fmt.Errorf("card template %s has no [[IMG_row_col]] placeholders", g.templatePath)
+	}
+	return string(data), nil
+}
+
+// cardLogo returns the logo from the branded template as a data URL
+func cardLogo() string {
+	if m := logoPattern.FindStringSubmatch(brandedTemplate); m != nil {
+		return m[1]
+	}
+	return ""
+}
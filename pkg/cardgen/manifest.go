@@ -0,0 +1,64 @@
+package cardgen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ManifestFile is the name of the card manifest in the cards directory
+const ManifestFile = "HolidayBingo_cards.json"
+
+// SaveManifest adds cards to the manifest at path, so printed cards can
+// later be claimed by ID and played in the browser. Cards already in the
+// manifest are replaced.
+func SaveManifest(cards []Card, path string) error {
+	existing, err := LoadManifest(path)
+	if err != nil {
+		return err
+	}
+
+	index := make(map[string]int, len(existing))
+	for i, c := range existing {
+		index[c.ID] = i
+	}
+	for _, c := range cards {
+		if i, ok := index[c.ID]; ok {
+			existing[i] = c
+		} else {
+			index[c.ID] = len(existing)
+			existing = append(existing, c)
+		}
+	}
+
+	data, err := json.MarshalIndent(existing, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding card manifest: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error saving card manifest: %v", err)
+	}
+	return nil
+}
+
+// LoadManifest returns the cards in the manifest at path, or none if there
+// is no manifest yet
+func LoadManifest(path string) ([]Card, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading card manifest: %v", err)
+	}
+	var cards []Card
+	if err := json.Unmarshal(data, &cards); err != nil {
+		return nil, fmt.Errorf("error parsing card manifest %s: %v", path, err)
+	}
+	return cards, nil
+}
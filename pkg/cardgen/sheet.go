@@ -0,0 +1,143 @@
+package cardgen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"holidaybingo/pkg/game"
+)
+
+// Caller sheet layout, in mm
+const (
+	sheetColumns   = 6
+	sheetRows      = 6
+	sheetThumbSize = 24.0
+	sheetRowHeight = 39.0
+	sheetTop       = margin + 18 // Below the title
+)
+
+// SheetEntry is one image on a caller sheet
+type SheetEntry struct {
+	Number  int
+	Image   string // Path of the image
+	Caption string
+	Time    time.Time // When the image was called; zero on the master sheet
+}
+
+// MasterSheetEntries lists every image set on the generator, numbered in
+// alphabetical order of caption so the host can find any image quickly
+func (g *Generator) MasterSheetEntries() []SheetEntry {
+	entries := make([]SheetEntry, len(g.images))
+	for i, path := range g.images {
+		entries[i] = SheetEntry{Image: path, Caption: g.catalog.Caption(path)}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return strings.ToLower(entries[i].Caption) < strings.ToLower(entries[j].Caption)
+	})
+	for i := range entries {
+		entries[i].Number = i + 1
+	}
+	return entries
+}
+
+// CallSheetEntries lists calls in the order they were made, with their times
+func CallSheetEntries(calls []game.Call) []SheetEntry {
+	entries := make([]SheetEntry, len(calls))
+	for i, call := range calls {
+		entries[i] = SheetEntry{Number: call.Number, Image: call.Item.Path, Caption: call.Item.Caption, Time: call.Time}
+	}
+	return entries
+}
+
+// SaveMasterSheet saves a caller master sheet to outputPath: every image
+// in the deck as a numbered thumbnail with its caption
+func (g *Generator) SaveMasterSheet(outputPath string) (*Report, error) {
+	return g.SaveSheet("Caller Master Sheet", g.MasterSheetEntries(), outputPath)
+}
+
+// SaveCallSheet saves an after-game sheet to outputPath showing the calls
+// in the order they were made, with their times
+func (g *Generator) SaveCallSheet(calls []game.Call, outputPath string) (*Report, error) {
+	title := "Call Sheet"
+	if len(calls) > 0 {
+		title = fmt.Sprintf("Call Sheet - %s", calls[0].Time.Format("2 Jan 2006"))
+	}
+	return g.SaveSheet(title, CallSheetEntries(calls), outputPath)
+}
+
+// SaveSheet saves entries to outputPath as a grid of numbered thumbnails.
+// Images that cannot be printed are handled by the missing image policy;
+// placeholders are listed in the report under the sheet's title.
+func (g *Generator) SaveSheet(title string, entries []SheetEntry, outputPath string) (*Report, error) {
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %v", err)
+	}
+
+	paths := make([]string, len(entries))
+	for i, e := range entries {
+		paths[i] = e.Image
+	}
+	tiles, failed := g.prerenderPaths(paths)
+	if len(failed) > 0 && g.policy == FailOnMissing {
+		return nil, ImageError(failed)
+	}
+
+	sheet := CardReport{CardID: title}
+	pdf := newPDF()
+	tr := pdf.UnicodeTranslatorFromDescriptor("") // Core fonts use cp1252
+	perPage := sheetColumns * sheetRows
+	pages := (len(entries) + perPage - 1) / perPage
+	colWidth := (pageWidth - 2*margin) / sheetColumns
+
+	for i, e := range entries {
+		if i%perPage == 0 {
+			pdf.AddPage()
+			pdf.SetFont("Arial", "B", 18)
+			pdf.Text(margin, margin+8, tr(title))
+			pdf.SetFont("Arial", "", 10)
+			page := fmt.Sprintf("%d images - page %d of %d", len(entries), i/perPage+1, pages)
+			pdf.Text(pageWidth-margin-pdf.GetStringWidth(page), margin+8, page)
+		}
+
+		slot := i % perPage
+		x := margin + float64(slot%sheetColumns)*colWidth
+		y := sheetTop + float64(slot/sheetColumns)*sheetRowHeight
+		thumbX := x + (colWidth-sheetThumbSize)/2
+
+		// Number in the corner of the cell
+		pdf.SetFont("Arial", "B", 10)
+		pdf.Text(x+1, y+4, fmt.Sprintf("%d", e.Number))
+
+		if t, ok := tiles[e.Image]; ok {
+			opts := registerTile(pdf, t)
+			pdf.ImageOptions(t.name, thumbX, y+5, sheetThumbSize, sheetThumbSize, false, opts, 0, "")
+		} else {
+			pdf.SetDashPattern([]float64{1, 1}, 0)
+			pdf.Rect(thumbX, y+5, sheetThumbSize, sheetThumbSize, "D")
+			pdf.SetDashPattern([]float64{}, 0)
+			sheet.Substitutions = append(sheet.Substitutions, Substitution{Square: i, Image: e.Image, Err: failed[e.Image]})
+		}
+
+		// Caption, and the time on the call sheet
+		pdf.SetFont("Arial", "", 7)
+		pdf.SetXY(x, y+5+sheetThumbSize+1)
+		pdf.CellFormat(colWidth, 3, fitText(pdf, tr(e.Caption), colWidth-1), "", 2, "C", false, 0, "")
+		if !e.Time.IsZero() {
+			pdf.CellFormat(colWidth, 3, e.Time.Format("15:04:05"), "", 2, "C", false, 0, "")
+		}
+	}
+	if len(entries) == 0 {
+		pdf.AddPage()
+		pdf.SetFont("Arial", "B", 18)
+		pdf.Text(margin, margin+8, tr(title))
+	}
+
+	if err := pdf.OutputFileAndClose(outputPath); err != nil {
+		return nil, fmt.Errorf("failed to save PDF: %v", err)
+	}
+	return &Report{Cards: []CardReport{sheet}}, nil
+}
@@ -0,0 +1,84 @@
+package cardgen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"holidaybingo/pkg/game"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// summaryColumns is how many columns of calls the game summary prints
+const summaryColumns = 3
+
+// SaveGameSummary writes a PDF summary of a session for sending out with
+// the winners: each round's pattern, winners, false claims and calls.
+func SaveGameSummary(rounds []game.RoundSummary, outputPath string) error {
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %v", err)
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(true, margin)
+	tr := pdf.UnicodeTranslatorFromDescriptor("") // Core fonts use cp1252
+	pdf.AddPage()
+
+	pdf.SetFont("Arial", "B", 20)
+	pdf.CellFormat(0, 12, "Holiday Bingo - Game Summary", "", 1, "C", false, 0, "")
+	if len(rounds) > 0 {
+		pdf.SetFont("Arial", "", 11)
+		pdf.CellFormat(0, 6, rounds[0].Started.Format("Monday 2 January 2006"), "", 1, "C", false, 0, "")
+	}
+
+	colWidth := (pageWidth - 2*margin) / summaryColumns
+	for _, r := range rounds {
+		pdf.Ln(6)
+		pdf.SetFont("Arial", "B", 14)
+		times := r.Started.Format("15:04")
+		if !r.Ended.IsZero() {
+			times += " - " + r.Ended.Format("15:04")
+		}
+		pdf.CellFormat(0, 8, tr(fmt.Sprintf("Round %d: %s (%s)", r.Round, r.Pattern, times)), "B", 1, "L", false, 0, "")
+
+		pdf.SetFont("Arial", "", 11)
+		winners := "none"
+		if w := r.Winners(); len(w) > 0 {
+			winners = strings.Join(w, ", ")
+		}
+		falseClaims := len(r.Claims) - len(r.Winners())
+		pdf.MultiCell(0, 6, tr(fmt.Sprintf("Winners: %s", winners)), "", "L", false)
+		pdf.MultiCell(0, 6, fmt.Sprintf("%d calls, %d false claims, %d corrections", len(r.Calls), falseClaims, r.Corrections), "", "L", false)
+		pdf.Ln(2)
+
+		// Calls in call order, filling each row across the columns
+		pdf.SetFont("Arial", "", 9)
+		for i, call := range r.Calls {
+			line := fmt.Sprintf("%d. %s  %s", call.Number, call.Item.Caption, call.Time.Format("15:04"))
+			ln := 0
+			if (i+1)%summaryColumns == 0 || i == len(r.Calls)-1 {
+				ln = 1
+			}
+			pdf.CellFormat(colWidth, 5, fitText(pdf, tr(line), colWidth-2), "", ln, "L", false, 0, "")
+		}
+	}
+
+	if err := pdf.OutputFileAndClose(outputPath); err != nil {
+		return fmt.Errorf("failed to save PDF: %v", err)
+	}
+	return nil
+}
+
+// fitText shortens s with an ellipsis until it fits in width at the current font
+func fitText(pdf *gofpdf.Fpdf, s string, width float64) string {
+	if pdf.GetStringWidth(s) <= width {
+		return s
+	}
+	for len(s) > 0 && pdf.GetStringWidth(s+"...") > width {
+		s = s[:len(s)-1]
+	}
+	return s + "..."
+}
@@ -0,0 +1,139 @@
+package cardgen
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/jung-kurt/gofpdf"
+	"holidaybingo/pkg/imgproc"
+)
+
+// DefaultDPI is the print resolution tiles are rendered at
+const DefaultDPI = 300
+
+// tile is an image pre-rendered for printing
+type tile struct {
+	name      string // Name the image is registered under in a PDF
+	data      []byte
+	imageType string // gofpdf image type, JPG or PNG
+}
+
+// SetDPI sets the print resolution that images are rendered at
+func (g *Generator) SetDPI(dpi int) {
+	if dpi > 0 {
+		g.dpi = dpi
+	}
+}
+
+// tilePixels returns the edge length, in pixels, of a tile printed imageSize mm wide
+func (g *Generator) tilePixels() int {
+	return int(math.Ceil(imageSize / 25.4 * float64(g.dpi)))
+}
+
+// printImage returns the square tile for imgPath at print resolution
+func (g *Generator) printImage(imgPath string) (*tile, error) {
+	size := g.tilePixels()
+	crop := g.catalog.Crop(imgPath, g.crop)
+	optimize := func(src []byte) ([]byte, error) {
+		return imgproc.Tile(src, size, crop)
+	}
+
+	var data []byte
+	var err error
+	if g.cache != nil {
+		variant := fmt.Sprintf("v%d-tile%d-%s", imgproc.Version, size, strings.ReplaceAll(crop.String(), ",", "x"))
+		data, err = g.cache.Get(imgPath, variant, optimize)
+	} else {
+		var src []byte
+		if src, err = os.ReadFile(imgPath); err == nil {
+			data, err = optimize(src)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Name tiles by content, so identical images are embedded only once
+	sum := sha1.Sum(data)
+	t := &tile{name: hex.EncodeToString(sum[:]), data: data, imageType: "PNG"}
+	// Tile only produces JPEG and PNG
+	if http.DetectContentType(data) == "image/jpeg" {
+		t.imageType = "JPG"
+	}
+	return t, nil
+}
+
+// prerender renders every image used on cards once, on a pool of workers.
+// Images that fail are left out of the result along with their error.
+func (g *Generator) prerender(cards []Card) (map[string]*tile, map[string]error) {
+	var paths []string
+	for _, card := range cards {
+		for i, square := range card.Squares {
+			if i != freeIndex {
+				paths = append(paths, square)
+			}
+		}
+	}
+	return g.prerenderPaths(paths)
+}
+
+// prerenderPaths renders each distinct image in paths once, on a pool of
+// workers. Images that fail are left out of the result along with their error.
+func (g *Generator) prerenderPaths(all []string) (map[string]*tile, map[string]error) {
+	var paths []string
+	seen := map[string]bool{}
+	for _, path := range all {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		tiles  = map[string]*tile{}
+		failed = map[string]error{}
+		jobs   = make(chan string)
+	)
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				t, err := g.printImage(path)
+				mu.Lock()
+				if err != nil {
+					failed[path] = err
+				} else {
+					tiles[path] = t
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, path := range paths {
+		jobs <- path
+	}
+	close(jobs)
+	wg.Wait()
+
+	return tiles, failed
+}
+
+// registerTile adds t to pdf unless an identical image is already there
+func registerTile(pdf *gofpdf.Fpdf, t *tile) gofpdf.ImageOptions {
+	opts := gofpdf.ImageOptions{ImageType: t.imageType}
+	if pdf.GetImageInfo(t.name) == nil {
+		pdf.RegisterImageOptionsReader(t.name, opts, bytes.NewReader(t.data))
+	}
+	return opts
+}
@@ -0,0 +1,101 @@
+package cardgen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"holidaybingo/pkg/imgproc"
+)
+
+// MissingPolicy decides what happens when an image cannot be printed
+type MissingPolicy int
+
+const (
+	// FailOnMissing refuses to generate cards with unusable images
+	FailOnMissing MissingPolicy = iota
+	// UsePlaceholder prints the image's caption in a marked box instead
+	UsePlaceholder
+)
+
+// SetMissingPolicy sets how missing or undecodable images are handled
+func (g *Generator) SetMissingPolicy(p MissingPolicy) {
+	g.policy = p
+}
+
+// ImageError lists the images that could not be used, with the reason for each
+type ImageError map[string]error
+
+func (e ImageError) Error() string {
+	paths := make([]string, 0, len(e))
+	for path := range e {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	lines := make([]string, 0, len(paths))
+	for _, path := range paths {
+		lines = append(lines, fmt.Sprintf("  %s: %v", path, e[path]))
+	}
+	return fmt.Sprintf("%d images cannot be printed:\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+// Validate checks that every image set on the generator exists and decodes.
+// It returns an ImageError describing each image that does not.
+func (g *Generator) Validate() error {
+	failed := ImageError{}
+	for _, path := range g.images {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			_, _, err = imgproc.Decode(data)
+		}
+		if err != nil {
+			failed[path] = err
+		}
+	}
+	if len(failed) > 0 {
+		return failed
+	}
+	return nil
+}
+
+// Substitution records a square printed with a placeholder instead of its image
+type Substitution struct {
+	Square int // Index into Card.Squares
+	Image  string
+	Err    error
+}
+
+// CardReport lists the substitutions made on one card
+type CardReport struct {
+	CardID        string
+	Substitutions []Substitution
+}
+
+// Report describes what was printed for each card
+type Report struct {
+	Cards []CardReport
+}
+
+// HasSubstitutions reports whether any card has a placeholder square
+func (r *Report) HasSubstitutions() bool {
+	for _, c := range r.Cards {
+		if len(c.Substitutions) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// String summarises the substitutions, one line per square
+func (r *Report) String() string {
+	var b strings.Builder
+	for _, c := range r.Cards {
+		for _, s := range c.Substitutions {
+			fmt.Fprintf(&b, "Card %s square %d: placeholder for %s (%v)\n", c.CardID, s.Square+1, filepath.Base(s.Image), s.Err)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,103 @@
+package catalog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"holidaybingo/pkg/imgproc"
+)
+
+// FileName is the name of the catalog file inside an image directory
+const FileName = "catalog.json"
+
+// Entry holds what we know about one image beyond its pixels
+type Entry struct {
+	File    string `json:"file"`
+	Caption string `json:"caption,omitempty"`
+	Crop    string `json:"crop,omitempty"` // center, entropy or x,y; see imgproc.ParseCrop
+}
+
+// Catalog describes the images in an image directory. It is stored as
+// catalog.json next to the images, and every field is optional, so an
+// image directory without a catalog works as before.
+type Catalog struct {
+	path    string
+	entries map[string]*Entry
+}
+
+// Load reads the catalog of dir. A missing catalog yields an empty one.
+func Load(dir string) (*Catalog, error) {
+	c := &Catalog{
+		path:    filepath.Join(dir, FileName),
+		entries: map[string]*Entry{},
+	}
+
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog: %v", err)
+	}
+
+	var entries []*Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", c.path, err)
+	}
+	for _, e := range entries {
+		if _, err := imgproc.ParseCrop(e.Crop, imgproc.Crop{}); err != nil {
+			return nil, fmt.Errorf("%s: %s: %v", c.path, e.File, err)
+		}
+		c.entries[e.File] = e
+	}
+	return c, nil
+}
+
+// Caption returns the caption of an image. Without a catalog entry the
+// caption is made from the file name, so "candy_cane.png" reads "Candy Cane".
+func (c *Catalog) Caption(file string) string {
+	if c != nil {
+		if e, ok := c.entries[filepath.Base(file)]; ok && e.Caption != "" {
+			return e.Caption
+		}
+	}
+	return CaptionFromName(file)
+}
+
+// Crop returns how an image should be cut to a square tile, falling back to def
+func (c *Catalog) Crop(file string, def imgproc.Crop) imgproc.Crop {
+	if c == nil {
+		return def
+	}
+	e, ok := c.entries[filepath.Base(file)]
+	if !ok {
+		return def
+	}
+	crop, _ := imgproc.ParseCrop(e.Crop, def) // Validated by Load
+	return crop
+}
+
+// contentHash matches the hash suffix provider.Save adds to file names
+var contentHash = regexp.MustCompile(`^[0-9a-f]{12}$`)
+
+// CaptionFromName turns an image file name into a readable caption
+func CaptionFromName(file string) string {
+	name := filepath.Base(file)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	name = strings.NewReplacer("_", " ", "-", " ").Replace(name)
+
+	words := strings.Fields(name)
+	if len(words) > 1 && contentHash.MatchString(words[len(words)-1]) {
+		words = words[:len(words)-1]
+	}
+	for i, w := range words {
+		r := []rune(w)
+		words[i] = strings.ToUpper(string(r[:1])) + string(r[1:])
+	}
+	return strings.Join(words, " ")
+}
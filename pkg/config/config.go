@@ -0,0 +1,232 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// DefaultPath is the config file read when BINGO_CONFIG is not set
+const DefaultPath = "holidaybingo.json"
+
+// Config holds the settings shared by the caller app and the tools
+type Config struct {
+	ImageDir  string `json:"image_dir"`
+	CardsDir  string `json:"cards_dir"`
+	CacheDir  string `json:"cache_dir"`
+	ImageSize int    `json:"image_size"`
+	Provider  string `json:"provider"`
+	Source    string `json:"source"`
+	Debug     bool   `json:"debug"`
+
+	// JournalFile is where the caller saves the game in progress
+	JournalFile string `json:"journal_file"`
+
+	// WebAddr is the address the browser caller board listens on
+	WebAddr string `json:"web_addr"`
+
+	// AutoCallSeconds is the time between calls in the caller's auto-call mode
+	AutoCallSeconds int `json:"auto_call_seconds"`
+
+	// AutoDaub marks called squares on digital cards for the players
+	AutoDaub bool `json:"auto_daub"`
+
+	// Keys overrides the caller's keyboard shortcuts, as key names by action
+	Keys map[string][]string `json:"keys"`
+
+	// UnsplashAPIKey is a secret, so it is only read from .env and the environment
+	UnsplashAPIKey string `json:"-"`
+}
+
+// Defaults returns the built-in settings
+func Defaults() Config {
+	cacheDir := filepath.Join(".cache", "images")
+	if dir, err := os.UserCacheDir(); err == nil {
+		cacheDir = filepath.Join(dir, "holidaybingo", "images")
+	}
+	journalFile := "game.journal"
+	if dir, err := os.UserConfigDir(); err == nil {
+		journalFile = filepath.Join(dir, "holidaybingo", "game.journal")
+	}
+	return Config{
+		ImageDir:  "img",
+		CardsDir:  "cards",
+		CacheDir:  cacheDir,
+		ImageSize: 800,
+		Provider:  "unsplash",
+
+		JournalFile:     journalFile,
+		WebAddr:         ":8080",
+		AutoCallSeconds: 20,
+	}
+}
+
+// setting describes how one field is named in the environment and read from a string
+type setting struct {
+	env   string
+	value func(c *Config) string
+	set   func(c *Config, v string) error
+}
+
+var settings = []setting{
+	{"BINGO_IMAGE_DIR", func(c *Config) string { return c.ImageDir }, func(c *Config, v string) error { c.ImageDir = v; return nil }},
+	{"BINGO_CARDS_DIR", func(c *Config) string { return c.CardsDir }, func(c *Config, v string) error { c.CardsDir = v; return nil }},
+	{"BINGO_CACHE_DIR", func(c *Config) string { return c.CacheDir }, func(c *Config, v string) error { c.CacheDir = v; return nil }},
+	{"BINGO_IMAGE_SIZE", func(c *Config) string { return strconv.Itoa(c.ImageSize) }, func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("BINGO_IMAGE_SIZE must be a positive number, got %q", v)
+		}
+		c.ImageSize = n
+		return nil
+	}},
+	{"BINGO_PROVIDER", func(c *Config) string { return c.Provider }, func(c *Config, v string) error { c.Provider = v; return nil }},
+	{"BINGO_SOURCE", func(c *Config) string { return c.Source }, func(c *Config, v string) error { c.Source = v; return nil }},
+	{"BINGO_DEBUG", func(c *Config) string { return strconv.FormatBool(c.Debug) }, func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("BINGO_DEBUG must be true or false, got %q", v)
+		}
+		c.Debug = b
+		return nil
+	}},
+	{"BINGO_JOURNAL", func(c *Config) string { return c.JournalFile }, func(c *Config, v string) error { c.JournalFile = v; return nil }},
+	{"BINGO_WEB_ADDR", func(c *Config) string { return c.WebAddr }, func(c *Config, v string) error { c.WebAddr = v; return nil }},
+	{"BINGO_AUTO_CALL_SECONDS", func(c *Config) string { return strconv.Itoa(c.AutoCallSeconds) }, func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("BINGO_AUTO_CALL_SECONDS must be a positive number, got %q", v)
+		}
+		c.AutoCallSeconds = n
+		return nil
+	}},
+	{"BINGO_AUTO_DAUB", func(c *Config) string { return strconv.FormatBool(c.AutoDaub) }, func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("BINGO_AUTO_DAUB must be true or false, got %q", v)
+		}
+		c.AutoDaub = b
+		return nil
+	}},
+	{"BINGO_KEYS", func(c *Config) string { return formatKeys(c.Keys) }, func(c *Config, v string) error {
+		keys, err := parseKeys(v)
+		if err != nil {
+			return err
+		}
+		if c.Keys == nil {
+			c.Keys = make(map[string][]string)
+		}
+		for action, names := range keys {
+			c.Keys[action] = names
+		}
+		return nil
+	}},
+	{"UNSPLASH_API_KEY", func(c *Config) string { return c.UnsplashAPIKey }, func(c *Config, v string) error { c.UnsplashAPIKey = v; return nil }},
+}
+
+// parseKeys reads key bindings written as "next=Space,Right;bingo=B"
+func parseKeys(v string) (map[string][]string, error) {
+	keys := make(map[string][]string)
+	for _, binding := range strings.Split(v, ";") {
+		binding = strings.TrimSpace(binding)
+		if binding == "" {
+			continue
+		}
+		action, names, ok := strings.Cut(binding, "=")
+		action = strings.TrimSpace(action)
+		if !ok || action == "" {
+			return nil, fmt.Errorf("BINGO_KEYS entries must look like action=Key,Key, got %q", binding)
+		}
+		var list []string
+		for _, name := range strings.Split(names, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				list = append(list, name)
+			}
+		}
+		keys[action] = list
+	}
+	return keys, nil
+}
+
+// formatKeys writes key bindings in the form read by parseKeys
+func formatKeys(keys map[string][]string) string {
+	actions := make([]string, 0, len(keys))
+	for action := range keys {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+
+	bindings := make([]string, len(actions))
+	for i, action := range actions {
+		bindings[i] = action + "=" + strings.Join(keys[action], ",")
+	}
+	return strings.Join(bindings, ";")
+}
+
+// Load builds the configuration from, in increasing order of precedence,
+// the defaults, the config file, a .env file and the environment. The
+// config file and .env are optional, and nothing is required at this
+// stage; commands call Require for the settings they need.
+func Load() (*Config, error) {
+	cfg := Defaults()
+
+	path := os.Getenv("BINGO_CONFIG")
+	explicit := path != ""
+	if !explicit {
+		path = DefaultPath
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %v", path, err)
+		}
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+		// No config file, keep the defaults
+	default:
+		return nil, fmt.Errorf("error reading config file: %v", err)
+	}
+
+	dotenv, err := godotenv.Read()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("error loading .env file: %v", err)
+	}
+
+	for _, s := range settings {
+		v, ok := os.LookupEnv(s.env)
+		if !ok {
+			v, ok = dotenv[s.env]
+		}
+		if !ok || v == "" {
+			continue
+		}
+		if err := s.set(&cfg, v); err != nil {
+			return nil, err
+		}
+	}
+	return &cfg, nil
+}
+
+// Require reports every setting in names, given by environment variable
+// name, that has no value
+func (c *Config) Require(names ...string) error {
+	var missing []string
+	for _, name := range names {
+		for _, s := range settings {
+			if s.env == name && s.value(c) == "" {
+				missing = append(missing, name)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required settings: %s (set them in the environment or .env)", strings.Join(missing, ", "))
+	}
+	return nil
+}
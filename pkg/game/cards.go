@@ -0,0 +1,238 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Card layout
+const (
+	CardSquares = 25
+	FreeSquare  = 12 // The centre square, marked from the start
+)
+
+var (
+	// ErrNoCard is returned for a card ID nobody has joined with
+	ErrNoCard = errors.New("no such card")
+	// ErrCardTaken is returned when joining with a card already in play
+	ErrCardTaken = errors.New("card is already in play")
+	// ErrBadSquare is returned when marking a square outside the card
+	ErrBadSquare = errors.New("no such square")
+	// ErrNotCalled is returned when marking a square that has not been called
+	ErrNotCalled = errors.New("that square has not been called")
+	// ErrNoBingo is returned for a claim on a card that does not show the pattern
+	ErrNoBingo = errors.New("card does not show the pattern")
+)
+
+// Card is a player's digital card. Squares hold item IDs; the free square
+// is empty.
+type Card struct {
+	ID      string              `json:"id"`
+	Player  string              `json:"player"`
+	Squares [CardSquares]string `json:"squares"`
+	Marked  [CardSquares]bool   `json:"marked"`
+}
+
+// PendingClaim is a bingo sent in by a player and waiting for the host
+type PendingClaim struct {
+	CardID string    `json:"card_id"`
+	Player string    `json:"player"`
+	Time   time.Time `json:"time"`
+}
+
+// AddCard puts a player's card in play, with only the free square marked,
+// or every called square with auto-daub on
+func (e *Engine) AddCard(c Card) error {
+	e.mu.Lock()
+	if _, ok := e.cards[c.ID]; ok {
+		e.mu.Unlock()
+		return ErrCardTaken
+	}
+	c.Marked = [CardSquares]bool{}
+	c.Marked[FreeSquare] = true
+	if e.autoDaub {
+		e.daub(&c, e.called())
+	}
+	e.cards[c.ID] = &c
+	e.cardsChanged = true
+	logger.Info("card in play", "card", c.ID, "player", c.Player)
+	e.mu.Unlock()
+	e.notify()
+	return nil
+}
+
+// Card returns a card in play
+func (e *Engine) Card(id string) (Card, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	c, ok := e.cards[id]
+	if !ok {
+		return Card{}, false
+	}
+	return *c, true
+}
+
+// Mark marks or unmarks a square on a card. Only squares that have been
+// called can be marked.
+func (e *Engine) Mark(id string, square int, marked bool) (Card, error) {
+	e.mu.Lock()
+	c, ok := e.cards[id]
+	if !ok {
+		e.mu.Unlock()
+		return Card{}, ErrNoCard
+	}
+	if square < 0 || square >= CardSquares || square == FreeSquare {
+		e.mu.Unlock()
+		return Card{}, ErrBadSquare
+	}
+	if marked && !e.called()[c.Squares[square]] {
+		e.mu.Unlock()
+		return Card{}, ErrNotCalled
+	}
+	c.Marked[square] = marked
+	e.cardsChanged = true
+	card := *c
+	e.mu.Unlock()
+	e.notify()
+	return card, nil
+}
+
+// SubmitClaim checks a player's bingo. A card showing the pattern goes in
+// the host's verify queue and pauses the game; any other claim is logged as
+// false and ErrNoBingo returned. A card can only have one claim waiting at
+// a time.
+func (e *Engine) SubmitClaim(id string) (PendingClaim, error) {
+	e.mu.Lock()
+	if e.state != Running && e.state != Paused {
+		e.mu.Unlock()
+		return PendingClaim{}, ErrNotRunning
+	}
+	c, ok := e.cards[id]
+	if !ok {
+		e.mu.Unlock()
+		return PendingClaim{}, ErrNoCard
+	}
+	for _, p := range e.pending {
+		if p.CardID == id {
+			e.mu.Unlock()
+			return p, nil
+		}
+	}
+	now := time.Now()
+	e.logEvent(EventClaimSubmitted, len(e.calls), nil, fmt.Sprintf("%s claimed bingo on card %s", c.Player, id))
+	if !e.pattern.Complete(e.daubed(c)) {
+		e.recordClaim(Claim{Player: c.Player, Card: id, Arrived: now, Reason: fmt.Sprintf("no %s on the called squares", e.pattern)})
+		e.mu.Unlock()
+		e.notify()
+		return PendingClaim{}, ErrNoBingo
+	}
+	claim := PendingClaim{CardID: id, Player: c.Player, Time: now}
+	e.pending = append(e.pending, claim)
+	e.pause()
+	e.mu.Unlock()
+	e.notify()
+	return claim, nil
+}
+
+// ResolveClaim records the host's verdict on a claim from the verify
+// queue and removes it from the queue. A claim can only be accepted while
+// the card shows the pattern, so a call undone since it arrived can turn
+// it false.
+func (e *Engine) ResolveClaim(cardID string, valid bool) (Claim, error) {
+	e.mu.Lock()
+	index := -1
+	for i, p := range e.pending {
+		if p.CardID == cardID {
+			index = i
+		}
+	}
+	if index < 0 {
+		e.mu.Unlock()
+		return Claim{}, ErrNoCard
+	}
+	pending := e.pending[index]
+	if c, ok := e.cards[cardID]; valid && (!ok || !e.pattern.Complete(e.daubed(c))) {
+		e.mu.Unlock()
+		return Claim{}, ErrNoBingo
+	}
+	e.pending = append(e.pending[:index:index], e.pending[index+1:]...)
+	claim := e.recordClaim(Claim{Player: pending.Player, Card: cardID, Arrived: pending.Time, Valid: valid})
+	e.mu.Unlock()
+	e.notify()
+	return claim, nil
+}
+
+// cardList returns the cards in play ordered by ID. It must be called with
+// the lock held.
+func (e *Engine) cardList() []Card {
+	cards := make([]Card, 0, len(e.cards))
+	for _, c := range e.cards {
+		cards = append(cards, *c)
+	}
+	sort.Slice(cards, func(i, j int) bool { return cards[i].ID < cards[j].ID })
+	return cards
+}
+
+// clearCards wipes the marks off every card and drops waiting claims for a
+// new round. It must be called with the lock held.
+func (e *Engine) clearCards() {
+	for _, c := range e.cards {
+		c.Marked = [CardSquares]bool{}
+		c.Marked[FreeSquare] = true
+	}
+	e.pending = nil
+	e.cardsChanged = true
+}
+
+// SetAutoDaub turns auto-daub on or off. With it on, every call marks the
+// item on each card in play.
+func (e *Engine) SetAutoDaub(on bool) {
+	e.mu.Lock()
+	if on == e.autoDaub {
+		e.mu.Unlock()
+		return
+	}
+	e.autoDaub = on
+	if on {
+		called := e.called()
+		for _, c := range e.cards {
+			e.daub(c, called)
+		}
+		e.cardsChanged = true
+	}
+	e.mu.Unlock()
+	e.notify()
+}
+
+// called returns the IDs of the items called so far. It must be called
+// with the lock held.
+func (e *Engine) called() map[string]bool {
+	called := make(map[string]bool, len(e.calls))
+	for _, call := range e.calls {
+		called[call.Item.ID] = true
+	}
+	return called
+}
+
+// daub marks the called squares on c. It must be called with the lock held.
+func (e *Engine) daub(c *Card, called map[string]bool) {
+	for i, id := range c.Squares {
+		if called[id] {
+			c.Marked[i] = true
+		}
+	}
+}
+
+// daubed returns the squares on c that are both marked and called, so a
+// mark left on a call since undone does not count. It must be called with
+// the lock held.
+func (e *Engine) daubed(c *Card) [CardSquares]bool {
+	called := e.called()
+	var daubed [CardSquares]bool
+	for i, id := range c.Squares {
+		daubed[i] = c.Marked[i] && (i == FreeSquare || called[id])
+	}
+	return daubed
+}
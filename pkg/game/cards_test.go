@@ -0,0 +1,158 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// testCard returns a card with item s00 on square 0, s01 on square 1 and
+// so on, and a deck that calls its top row first
+func testCard(id string) (Card, []Item) {
+	c := Card{ID: id, Player: "Pat"}
+	var deck []Item
+	for i := range c.Squares {
+		if i == FreeSquare {
+			continue
+		}
+		c.Squares[i] = fmt.Sprintf("s%02d", i)
+		deck = append(deck, Item{ID: c.Squares[i]})
+	}
+	return c, deck
+}
+
+// startCardGame starts a game with card in play and the first calls made
+func startCardGame(t *testing.T, calls int) (*Engine, Card) {
+	t.Helper()
+	card, deck := testCard("ABC")
+	e := NewEngine()
+	e.NewGame(1, deck, false)
+	if err := e.AddCard(card); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < calls; i++ {
+		if _, err := e.Call(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return e, card
+}
+
+// markRow marks the top row of card
+func markRow(t *testing.T, e *Engine, card Card) {
+	t.Helper()
+	for square := 0; square < 5; square++ {
+		if _, err := e.Mark(card.ID, square, true); err != nil {
+			t.Fatalf("marking square %d: %v", square, err)
+		}
+	}
+}
+
+func TestMarkUncalledSquare(t *testing.T) {
+	e, card := startCardGame(t, 1)
+	if _, err := e.Mark(card.ID, 0, true); err != nil {
+		t.Errorf("marking a called square: %v", err)
+	}
+	if _, err := e.Mark(card.ID, 1, true); !errors.Is(err, ErrNotCalled) {
+		t.Errorf("marking an uncalled square returned %v, want ErrNotCalled", err)
+	}
+	if c, _ := e.Card(card.ID); c.Marked[1] {
+		t.Error("uncalled square was marked")
+	}
+}
+
+func TestAutoDaub(t *testing.T) {
+	e, card := startCardGame(t, 2)
+	e.SetAutoDaub(true)
+	if c, _ := e.Card(card.ID); !c.Marked[0] || !c.Marked[1] || c.Marked[2] {
+		t.Errorf("marks %v after turning on auto-daub, want squares 0 and 1", c.Marked[:5])
+	}
+	e.Call()
+	if c, _ := e.Card(card.ID); !c.Marked[2] || c.Marked[3] {
+		t.Errorf("marks %v after the next call, want squares 0 to 2", c.Marked[:5])
+	}
+}
+
+func TestClaims(t *testing.T) {
+	tests := []struct {
+		name    string
+		calls   int // Calls made before the claim
+		pending int // Claims waiting afterwards
+		err     error
+	}{
+		{name: "complete line", calls: 5, pending: 1},
+		{name: "incomplete line", calls: 4, err: ErrNoBingo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, card := startCardGame(t, tt.calls)
+			e.SetAutoDaub(true)
+
+			_, err := e.SubmitClaim(card.ID)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("SubmitClaim returned %v, want %v", err, tt.err)
+			}
+			s := e.Snapshot()
+			if len(s.Pending) != tt.pending {
+				t.Errorf("%d claims waiting, want %d", len(s.Pending), tt.pending)
+			}
+			if tt.err == nil {
+				if s.State != Paused {
+					t.Errorf("state %v after a claim, want paused", s.State)
+				}
+				return
+			}
+			if len(s.Claims) != 1 || s.Claims[0].Valid || s.Claims[0].Reason == "" {
+				t.Errorf("claims %+v, want one false claim with a reason", s.Claims)
+			}
+			if ev := s.LastEvent; ev.Kind != EventClaim || ev.Claim == nil || ev.Claim.Valid {
+				t.Errorf("last log entry %+v, want the false claim", ev)
+			}
+		})
+	}
+}
+
+func TestDuplicateClaim(t *testing.T) {
+	e, card := startCardGame(t, 5)
+	markRow(t, e, card)
+	first, err := e.SubmitClaim(card.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := e.SubmitClaim(card.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second != first {
+		t.Errorf("second claim %+v, want the waiting claim %+v", second, first)
+	}
+	if n := len(e.Snapshot().Pending); n != 1 {
+		t.Errorf("%d claims waiting, want 1", n)
+	}
+}
+
+func TestResolveClaimAfterUndo(t *testing.T) {
+	e, card := startCardGame(t, 5)
+	markRow(t, e, card)
+	if _, err := e.SubmitClaim(card.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Undo the pause for the claim, then the call that completed the line
+	play(t, e, []string{"undo", "undo"})
+	if _, err := e.ResolveClaim(card.ID, true); !errors.Is(err, ErrNoBingo) {
+		t.Errorf("accepting the claim returned %v, want ErrNoBingo", err)
+	}
+	if n := len(e.Snapshot().Pending); n != 1 {
+		t.Errorf("%d claims waiting after the refused verdict, want 1", n)
+	}
+
+	claim, err := e.ResolveClaim(card.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claim.Valid || len(e.Snapshot().Pending) != 0 {
+		t.Errorf("claim %+v with %d waiting, want a false claim and none waiting", claim, len(e.Snapshot().Pending))
+	}
+}
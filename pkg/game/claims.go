@@ -0,0 +1,168 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Pattern is the shape a card must show to win
+type Pattern string
+
+const (
+	PatternLine        Pattern = "line" // Any row, column or diagonal
+	PatternFourCorners Pattern = "four_corners"
+	PatternX           Pattern = "x" // Both diagonals
+	PatternBlackout    Pattern = "blackout"
+)
+
+// Patterns lists the supported patterns
+var Patterns = []Pattern{PatternLine, PatternFourCorners, PatternX, PatternBlackout}
+
+// String returns the name shown to players
+func (p Pattern) String() string {
+	switch p {
+	case PatternLine:
+		return "Line"
+	case PatternFourCorners:
+		return "Four Corners"
+	case PatternX:
+		return "X"
+	case PatternBlackout:
+		return "Blackout"
+	}
+	return string(p)
+}
+
+// wins returns the sets of squares, any one of which completes the pattern
+func (p Pattern) wins() [][]int {
+	const n = 5
+	var diag, anti []int
+	for i := 0; i < n; i++ {
+		diag = append(diag, i*n+i)
+		anti = append(anti, i*n+n-1-i)
+	}
+	switch p {
+	case PatternFourCorners:
+		return [][]int{{0, n - 1, n * (n - 1), n*n - 1}}
+	case PatternX:
+		return [][]int{append(diag, anti...)}
+	case PatternBlackout:
+		all := make([]int, CardSquares)
+		for i := range all {
+			all[i] = i
+		}
+		return [][]int{all}
+	}
+	wins := [][]int{diag, anti}
+	for i := 0; i < n; i++ {
+		var row, col []int
+		for j := 0; j < n; j++ {
+			row = append(row, i*n+j)
+			col = append(col, j*n+i)
+		}
+		wins = append(wins, row, col)
+	}
+	return wins
+}
+
+// Complete reports whether the marked squares of a card show the pattern
+func (p Pattern) Complete(marked [CardSquares]bool) bool {
+	for _, win := range p.wins() {
+		complete := true
+		for _, square := range win {
+			complete = complete && marked[square]
+		}
+		if complete {
+			return true
+		}
+	}
+	return false
+}
+
+// Claim is a bingo called by a player and the host's verdict on it
+type Claim struct {
+	Player string    `json:"player"`
+	Valid  bool      `json:"valid"`
+	Call   int       `json:"call"` // Number of calls made when the claim was checked
+	Time   time.Time `json:"time"`
+
+	Arrived time.Time `json:"arrived"`          // When the player claimed; claims are listed in this order
+	Card    string    `json:"card,omitempty"`   // Digital card the claim came from
+	Reason  string    `json:"reason,omitempty"` // Why the engine found the claim false
+}
+
+// SetPattern sets the pattern that wins the current round
+func (e *Engine) SetPattern(p Pattern) {
+	e.mu.Lock()
+	if p == e.pattern {
+		e.mu.Unlock()
+		return
+	}
+	e.pattern = p
+	e.logEvent(EventPattern, len(e.calls), nil, fmt.Sprintf("Pattern set to %s", p))
+	e.events[len(e.events)-1].Pattern = p
+	e.mu.Unlock()
+	e.notify()
+}
+
+// RecordClaim records the host's verdict on a player's bingo
+func (e *Engine) RecordClaim(player string, valid bool) Claim {
+	e.mu.Lock()
+	claim := e.recordClaim(Claim{Player: player, Valid: valid, Arrived: time.Now()})
+	e.mu.Unlock()
+	e.notify()
+	return claim
+}
+
+// recordClaim records the verdict on claim as of now. It must be called
+// with the lock held.
+func (e *Engine) recordClaim(claim Claim) Claim {
+	claim.Call = len(e.calls)
+	claim.Time = time.Now()
+	e.claims = append(e.claims, claim)
+	verdict := "false claim"
+	if claim.Valid {
+		verdict = "winner"
+	} else if claim.Reason != "" {
+		verdict = "false claim, " + claim.Reason
+	}
+	e.logEvent(EventClaim, claim.Call, nil, fmt.Sprintf("%s: %s", claim.Player, verdict))
+	e.events[len(e.events)-1].Claim = &claim
+	return claim
+}
+
+// Winners returns the players whose claims were valid, in order
+func (s Snapshot) Winners() []string {
+	var winners []string
+	for _, c := range s.Claims {
+		if c.Valid {
+			winners = append(winners, c.Player)
+		}
+	}
+	return winners
+}
+
+// ClaimEntry is a claim as listed for the host: checked, or waiting
+type ClaimEntry struct {
+	Player  string
+	Card    string // Empty for claims made in the room
+	Arrived time.Time
+	Waiting bool // The host has not checked it yet
+	Valid   bool
+	Reason  string
+}
+
+// ClaimsByArrival lists this round's claims, checked and waiting, in the
+// order players made them
+func (s Snapshot) ClaimsByArrival() []ClaimEntry {
+	entries := make([]ClaimEntry, 0, len(s.Claims)+len(s.Pending))
+	for _, c := range s.Claims {
+		entries = append(entries, ClaimEntry{Player: c.Player, Card: c.Card, Arrived: c.Arrived, Valid: c.Valid, Reason: c.Reason})
+	}
+	for _, p := range s.Pending {
+		entries = append(entries, ClaimEntry{Player: p.Player, Card: p.CardID, Arrived: p.Time, Waiting: true})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Arrived.Before(entries[j].Arrived) })
+	return entries
+}
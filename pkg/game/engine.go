@@ -0,0 +1,480 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"holidaybingo/pkg/logging"
+)
+
+var (
+	// ErrNotRunning is returned when calling while the game is paused or over
+	ErrNotRunning = errors.New("game is not running")
+	// ErrNoItems is returned when calling from an empty deck
+	ErrNoItems = errors.New("deck is empty")
+	// ErrLoading is returned when every loaded item has been called but
+	// more are still being added to the deck
+	ErrLoading = errors.New("waiting for more images to load")
+)
+
+// State is the phase a game is in
+type State int
+
+const (
+	Idle    State = iota // No game started
+	Running              // Items are being called
+	Paused               // Calling stopped while a bingo is checked
+	Ended
+)
+
+var logger = logging.New("game")
+
+// String returns the name of the state
+func (s State) String() string {
+	switch s {
+	case Idle:
+		return "idle"
+	case Running:
+		return "running"
+	case Paused:
+		return "paused"
+	case Ended:
+		return "ended"
+	}
+	return "unknown"
+}
+
+// MarshalText writes the state by name
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText reads a state written by MarshalText
+func (s *State) UnmarshalText(text []byte) error {
+	for _, state := range []State{Idle, Running, Paused, Ended} {
+		if state.String() == string(text) {
+			*s = state
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown game state %q", text)
+}
+
+// Item is one image in the deck
+type Item struct {
+	ID      string `json:"id"` // File name of the image
+	Caption string `json:"caption"`
+	Path    string `json:"path"`
+}
+
+// Call records an item being called
+type Call struct {
+	Number    int       `json:"number"` // 1 for the first call of a game
+	Item      Item      `json:"item"`
+	Time      time.Time `json:"time"`
+	Corrected bool      `json:"corrected,omitempty"` // An earlier call with this number was undone
+}
+
+// DefaultAutoInterval is the time between automatic calls unless set otherwise
+const DefaultAutoInterval = 20 * time.Second
+
+// Snapshot is a consistent copy of the engine's state
+type Snapshot struct {
+	State    State
+	Calls    []Call // In call order
+	DeckSize int
+	Loading  bool  // Items are still being added to the deck
+	Round    int   // Number of games started this session
+	Seed     int64 // Seed the deck was shuffled with
+	Pattern  Pattern
+	Claims   []Claim        // Bingo claims checked this round
+	Pending  []PendingClaim // Claims from digital cards waiting to be checked, in order of arrival
+	Cards    []Card         // Digital cards in play, ordered by ID
+	AutoDaub bool           // Calls mark the item on every digital card
+
+	UndoAction string // What Undo would revert; empty if nothing
+	RedoAction string // What Redo would repeat; empty if nothing
+	LastEvent  Event  // The latest entry in the game log, such as the correction just made
+
+	AutoCall     bool          // Items are called automatically
+	AutoInterval time.Duration // Time between automatic calls
+	NextAutoCall time.Time     // When the next automatic call is due; zero if none is scheduled
+}
+
+// Current returns the most recent call
+func (s Snapshot) Current() (Call, bool) {
+	if len(s.Calls) == 0 {
+		return Call{}, false
+	}
+	return s.Calls[len(s.Calls)-1], true
+}
+
+// Recent returns up to n of the calls before the current one, newest first
+func (s Snapshot) Recent(n int) []Call {
+	var recent []Call
+	for i := len(s.Calls) - 2; i >= 0 && len(recent) < n; i-- {
+		recent = append(recent, s.Calls[i])
+	}
+	return recent
+}
+
+// Listener is told about every change to the game
+type Listener func(Snapshot)
+
+// Engine runs a game: it owns the deck, the calls made so far and the
+// state of play. Front ends change the game only through the engine and
+// redraw themselves from the snapshots it sends to its listeners.
+type Engine struct {
+	mu        sync.Mutex
+	state     State
+	deck      []Item
+	next      int // Index in deck of the next item to call
+	calls     []Call
+	loading   bool
+	listeners []Listener
+	latest    *Snapshot // Snapshot waiting to be sent to the listeners
+	sending   bool      // A goroutine is sending snapshots to the listeners
+	round     int
+	events    []Event
+
+	undo      []memento
+	redo      []memento
+	withdrawn map[int]bool // Call numbers undone this game
+	seed      int64
+	pattern   Pattern
+	claims    []Claim
+
+	journal     *Journal
+	journaled   int  // Number of events already in the journal
+	deckChanged bool // The deck has changed since the last journal record
+
+	auto         bool
+	autoInterval time.Duration
+	autoTimer    *time.Timer
+	autoGen      int // Incremented on every reschedule, so a stale timer does nothing
+	nextAuto     time.Time
+
+	cards        map[string]*Card // Digital cards in play, by ID
+	cardsChanged bool             // Cards have changed since the last journal record
+	pending      []PendingClaim   // Claims from digital cards, in order of arrival
+	autoDaub     bool             // Calls mark the item on every card
+}
+
+// NewEngine creates an engine with no game in progress
+func NewEngine() *Engine {
+	return &Engine{
+		autoInterval: DefaultAutoInterval,
+		withdrawn:    make(map[int]bool),
+		pattern:      PatternLine,
+		cards:        make(map[string]*Card),
+	}
+}
+
+// Subscribe registers l to be called after every change. Listeners are
+// called one at a time, in the order of the changes, on a goroutine of the
+// engine's own. When changes come faster than the listeners keep up, they
+// are only sent the latest snapshot.
+func (e *Engine) Subscribe(l Listener) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.listeners = append(e.listeners, l)
+}
+
+// notify records the current state in the journal and queues it for the
+// listeners. It must be called without holding the lock.
+func (e *Engine) notify() {
+	e.mu.Lock()
+	e.writeJournal()
+	s := e.snapshot()
+	e.latest = &s
+	start := !e.sending
+	e.sending = true
+	e.mu.Unlock()
+
+	if start {
+		go e.send()
+	}
+}
+
+// send delivers queued snapshots to the listeners until none is left. Only
+// one send runs at a time, so snapshots arrive in order.
+func (e *Engine) send() {
+	for {
+		e.mu.Lock()
+		s := e.latest
+		e.latest = nil
+		if s == nil {
+			e.sending = false
+			e.mu.Unlock()
+			return
+		}
+		listeners := append([]Listener(nil), e.listeners...)
+		e.mu.Unlock()
+
+		for _, l := range listeners {
+			l(*s)
+		}
+	}
+}
+
+// Snapshot returns a copy of the current state
+func (e *Engine) Snapshot() Snapshot {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.snapshot()
+}
+
+func (e *Engine) snapshot() Snapshot {
+	s := Snapshot{
+		State:    e.state,
+		Calls:    append([]Call(nil), e.calls...),
+		DeckSize: len(e.deck),
+		Loading:  e.loading,
+		Round:    e.round,
+		Seed:     e.seed,
+		Pattern:  e.pattern,
+		Claims:   append([]Claim(nil), e.claims...),
+		Pending:  append([]PendingClaim(nil), e.pending...),
+		Cards:    e.cardList(),
+		AutoDaub: e.autoDaub,
+
+		AutoCall:     e.auto,
+		AutoInterval: e.autoInterval,
+		NextAutoCall: e.nextAuto,
+	}
+	if len(e.events) > 0 {
+		s.LastEvent = e.events[len(e.events)-1]
+	}
+	if len(e.undo) > 0 {
+		s.UndoAction = e.undo[len(e.undo)-1].action
+	}
+	if len(e.redo) > 0 {
+		s.RedoAction = e.redo[len(e.redo)-1].action
+	}
+	return s
+}
+
+// NewGame starts a game with items in calling order, shuffled with seed.
+// If loading is true more items will follow through AddItems, until
+// FinishLoading is called.
+func (e *Engine) NewGame(seed int64, items []Item, loading bool) {
+	e.mu.Lock()
+	e.state = Running
+	e.seed = seed
+	e.claims = nil
+	e.deck = append([]Item(nil), items...)
+	e.deckChanged = true
+	e.next = 0
+	e.calls = nil
+	e.loading = loading
+	e.round++
+	e.undo, e.redo = nil, nil
+	e.withdrawn = make(map[int]bool)
+	e.clearCards()
+	e.logEvent(EventNewGame, 0, nil, fmt.Sprintf("Round %d started", e.round))
+	e.schedule()
+	e.mu.Unlock()
+	e.notify()
+}
+
+// AddItems appends items to the end of the deck
+func (e *Engine) AddItems(items ...Item) {
+	e.mu.Lock()
+	e.deck = append(e.deck, items...)
+	e.deckChanged = true
+	e.mu.Unlock()
+	e.notify()
+}
+
+// FinishLoading marks the deck as complete
+func (e *Engine) FinishLoading() {
+	e.mu.Lock()
+	e.loading = false
+	e.mu.Unlock()
+	e.notify()
+}
+
+// Call calls the next item. Once the whole deck has been called, calling
+// starts again from the top.
+func (e *Engine) Call() (Call, error) {
+	e.mu.Lock()
+	call, err := e.call()
+	e.mu.Unlock()
+	if err != nil {
+		return Call{}, err
+	}
+	e.notify()
+	return call, nil
+}
+
+// call makes the next call with the lock held
+func (e *Engine) call() (Call, error) {
+	if e.state != Running {
+		return Call{}, ErrNotRunning
+	}
+	if e.next >= len(e.deck) {
+		if e.loading {
+			return Call{}, ErrLoading
+		}
+		if len(e.deck) == 0 {
+			return Call{}, ErrNoItems
+		}
+		e.next = 0
+	}
+
+	before := e.save()
+	number := len(e.calls) + 1
+	call := Call{Number: number, Item: e.deck[e.next], Time: time.Now(), Corrected: e.withdrawn[number]}
+	e.calls = append(e.calls, call)
+	e.next++
+	e.record(EventCall, describeCall(call), before)
+	e.logEvent(EventCall, call.Number, &call.Item, describeCall(call))
+	if e.autoDaub && len(e.cards) > 0 {
+		called := map[string]bool{call.Item.ID: true}
+		for _, c := range e.cards {
+			e.daub(c, called)
+		}
+		e.cardsChanged = true
+	}
+	e.schedule()
+	return call, nil
+}
+
+// Pause stops calling while a bingo is checked
+func (e *Engine) Pause() error {
+	e.mu.Lock()
+	if e.state != Running {
+		e.mu.Unlock()
+		return ErrNotRunning
+	}
+	e.pause()
+	e.mu.Unlock()
+	e.notify()
+	return nil
+}
+
+// pause stops a running game. It must be called with the lock held.
+func (e *Engine) pause() {
+	if e.state != Running {
+		return
+	}
+	before := e.save()
+	e.state = Paused
+	e.record(EventPause, "bingo pause", before)
+	e.logEvent(EventPause, len(e.calls), nil, "Bingo claimed, game paused")
+	e.schedule()
+}
+
+// Resume continues calling after a pause
+func (e *Engine) Resume() error {
+	e.mu.Lock()
+	if e.state != Paused {
+		e.mu.Unlock()
+		return errors.New("game is not paused")
+	}
+	before := e.save()
+	e.state = Running
+	e.record(EventResume, "continue", before)
+	e.logEvent(EventResume, len(e.calls), nil, "Game continued")
+	e.schedule()
+	e.mu.Unlock()
+	e.notify()
+	return nil
+}
+
+// SetAutoCall turns automatic calling on or off. While it is on, the next
+// item is called once the interval has passed since the previous call.
+// Pausing the game holds the timer, and automatic calling turns itself
+// off once every item in the deck has been called.
+func (e *Engine) SetAutoCall(on bool) {
+	e.mu.Lock()
+	e.auto = on
+	e.schedule()
+	e.mu.Unlock()
+	e.notify()
+}
+
+// SetAutoInterval sets the time between automatic calls
+func (e *Engine) SetAutoInterval(d time.Duration) {
+	if d <= 0 {
+		d = DefaultAutoInterval
+	}
+	e.mu.Lock()
+	e.autoInterval = d
+	e.schedule()
+	e.mu.Unlock()
+	e.notify()
+}
+
+// schedule starts the timer for the next automatic call, or stops it when
+// none is due. It must be called with the lock held.
+func (e *Engine) schedule() {
+	e.autoGen++
+	if e.autoTimer != nil {
+		e.autoTimer.Stop()
+		e.autoTimer = nil
+	}
+	e.nextAuto = time.Time{}
+	if !e.auto || e.state != Running {
+		return
+	}
+
+	gen := e.autoGen
+	e.nextAuto = time.Now().Add(e.autoInterval)
+	e.autoTimer = time.AfterFunc(e.autoInterval, func() {
+		e.autoCall(gen)
+	})
+}
+
+// autoCall makes the call for the timer started in generation gen
+func (e *Engine) autoCall(gen int) {
+	e.mu.Lock()
+	if gen != e.autoGen {
+		e.mu.Unlock()
+		return
+	}
+	if e.next >= len(e.deck) && !e.loading {
+		// The deck is exhausted; stop rather than start again from the top
+		e.auto = false
+		e.schedule()
+	} else if _, err := e.call(); err != nil {
+		// Still loading; try again after another interval
+		e.schedule()
+	}
+	e.mu.Unlock()
+	e.notify()
+}
+
+// End finishes the game and clears the deck. Ending a game that is not
+// in progress does nothing.
+func (e *Engine) End() {
+	e.mu.Lock()
+	if e.state == Idle || e.state == Ended {
+		e.mu.Unlock()
+		return
+	}
+	before := e.save()
+	e.logEvent(EventEnd, len(e.calls), nil, fmt.Sprintf("Round %d ended after %d calls", e.round, len(e.calls)))
+	e.record(EventEnd, "end of game", before)
+	e.state = Ended
+	e.deck = nil
+	e.deckChanged = true
+	e.next = 0
+	e.calls = nil
+	e.loading = false
+	e.auto = false
+	e.schedule()
+	e.mu.Unlock()
+	e.notify()
+}
+
+// describeCall names a call for the game log and undo prompts
+func describeCall(c Call) string {
+	caption := c.Item.Caption
+	if caption == "" {
+		caption = c.Item.ID
+	}
+	return fmt.Sprintf("call #%d (%s)", c.Number, caption)
+}
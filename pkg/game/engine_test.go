@@ -0,0 +1,63 @@
+package game
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Listeners must see the game move forward even when changes are made
+// from several goroutines at once
+func TestListenersSeeChangesInOrder(t *testing.T) {
+	items := make([]Item, 200)
+	for i := range items {
+		items[i] = Item{ID: fmt.Sprintf("item%03d", i)}
+	}
+	e := NewEngine()
+	e.NewGame(1, items, false)
+
+	var mu sync.Mutex
+	last, backwards := 0, 0
+	e.Subscribe(func(s Snapshot) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(s.Calls) < last {
+			backwards++
+		}
+		last = len(s.Calls)
+	})
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				e.Call()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Delivery is asynchronous; wait for the last snapshot to arrive
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		done := last == 160
+		mu.Unlock()
+		if done || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if backwards > 0 {
+		t.Errorf("listener saw the call count go backwards %d times", backwards)
+	}
+	if last != 160 {
+		t.Errorf("listener last saw %d calls, want 160", last)
+	}
+}
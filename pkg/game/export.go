@@ -0,0 +1,149 @@
+package game
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// RoundSummary is what happened in one round, after corrections
+type RoundSummary struct {
+	Round       int       `json:"round"`
+	Pattern     Pattern   `json:"pattern"`
+	Started     time.Time `json:"started"`
+	Ended       time.Time `json:"ended,omitempty"` // Zero if the round was not ended
+	Calls       []Call    `json:"calls"`
+	Claims      []Claim   `json:"claims,omitempty"`
+	Corrections int       `json:"corrections"` // Number of undos and redos
+}
+
+// Winners returns the players whose claims were valid
+func (r RoundSummary) Winners() []string {
+	var winners []string
+	for _, c := range r.Claims {
+		if c.Valid {
+			winners = append(winners, c.Player)
+		}
+	}
+	return winners
+}
+
+// Summarize replays a game log into one summary per round. Undone calls
+// are left out, so each round lists exactly the calls that stood.
+func Summarize(events []Event) []RoundSummary {
+	var rounds []RoundSummary
+	var r *RoundSummary
+	pattern := PatternLine
+	for _, ev := range events {
+		if ev.Kind == EventPattern {
+			// The pattern may be chosen before the round starts
+			pattern = ev.Pattern
+		}
+		if ev.Kind == EventNewGame || (ev.Kind == EventRestore && (r == nil || r.Round != ev.Round)) {
+			rounds = append(rounds, RoundSummary{Round: ev.Round, Pattern: pattern, Started: ev.Time})
+			r = &rounds[len(rounds)-1]
+			continue
+		}
+		if r == nil {
+			continue
+		}
+
+		switch ev.Kind {
+		case EventPattern:
+			r.Pattern = pattern
+		case EventCall:
+			if ev.Item != nil {
+				r.Calls = append(r.Calls, Call{Number: ev.Call, Item: *ev.Item, Time: ev.Time})
+			}
+		case EventClaim:
+			if ev.Claim != nil {
+				r.Claims = append(r.Claims, *ev.Claim)
+			}
+		case EventEnd:
+			r.Ended = ev.Time
+		case EventUndo:
+			r.Corrections++
+			switch ev.Reverts {
+			case EventCall:
+				if ev.Call < len(r.Calls) {
+					r.Calls = r.Calls[:ev.Call]
+				}
+			case EventEnd:
+				r.Ended = time.Time{}
+			}
+		case EventRedo:
+			r.Corrections++
+			switch ev.Reverts {
+			case EventCall:
+				if ev.Item != nil {
+					r.Calls = append(r.Calls, Call{Number: ev.Call, Item: *ev.Item, Time: ev.Time})
+				}
+			case EventEnd:
+				r.Ended = ev.Time
+			}
+		}
+	}
+	return rounds
+}
+
+// LogExport is the JSON form of a session's game log
+type LogExport struct {
+	Exported time.Time      `json:"exported"`
+	Rounds   []RoundSummary `json:"rounds"`
+	Events   []Event        `json:"events"`
+}
+
+// WriteJSON writes the game log with a summary of each round as JSON
+func WriteJSON(w io.Writer, events []Event) error {
+	export := LogExport{
+		Exported: time.Now(),
+		Rounds:   Summarize(events),
+		Events:   events,
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(export); err != nil {
+		return fmt.Errorf("error writing game log: %v", err)
+	}
+	return nil
+}
+
+// csvHeader names the columns written by WriteCSV
+var csvHeader = []string{"time", "round", "event", "call", "image_id", "caption", "player", "valid", "detail"}
+
+// WriteCSV writes the game log as CSV, one row per event
+func WriteCSV(w io.Writer, events []Event) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return fmt.Errorf("error writing game log: %v", err)
+	}
+	for _, ev := range events {
+		row := make([]string, len(csvHeader))
+		row[0] = ev.Time.Format(time.RFC3339)
+		row[1] = strconv.Itoa(ev.Round)
+		row[2] = string(ev.Kind)
+		if ev.Call > 0 {
+			row[3] = strconv.Itoa(ev.Call)
+		}
+		if ev.Item != nil {
+			row[4] = ev.Item.ID
+			row[5] = ev.Item.Caption
+		}
+		if ev.Claim != nil {
+			row[6] = ev.Claim.Player
+			row[7] = strconv.FormatBool(ev.Claim.Valid)
+		}
+		row[8] = ev.Detail
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("error writing game log: %v", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("error writing game log: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package game
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrNoGame is returned by ReadJournal when the journal holds no unfinished game
+var ErrNoGame = errors.New("no unfinished game")
+
+// Record is one line of the journal: the state of play after an action.
+// The deck is only written when it changes, and each record carries only
+// the events logged since the previous one.
+type Record struct {
+	Time        time.Time `json:"time"`
+	Seed        int64     `json:"seed"`
+	Round       int       `json:"round"`
+	State       State     `json:"state"`
+	DeckChanged bool      `json:"deck_changed,omitempty"`
+	Deck        []Item    `json:"deck,omitempty"`
+	Loading     bool      `json:"loading,omitempty"` // Images were still being added to the deck
+	Next        int       `json:"next"`
+	Calls       []Call    `json:"calls"`
+	Pattern     Pattern   `json:"pattern"`
+	Claims      []Claim   `json:"claims,omitempty"`
+	Events      []Event   `json:"events,omitempty"`
+
+	// Digital cards are written, like the deck, only when they change
+	CardsChanged bool           `json:"cards_changed,omitempty"`
+	Cards        []Card         `json:"cards,omitempty"`
+	Pending      []PendingClaim `json:"pending,omitempty"`
+	AutoDaub     bool           `json:"auto_daub,omitempty"`
+}
+
+// Journal is an append-only file of records. Every record is synced to
+// disk before the action that produced it is reported, so a crash loses at
+// most a torn final line.
+type Journal struct {
+	mu   sync.Mutex
+	f    *os.File
+	path string
+}
+
+// OpenJournal opens the journal at path. With resume it appends to the
+// existing journal, after cutting off any torn final line; otherwise any
+// previous journal is discarded.
+func OpenJournal(path string, resume bool) (*Journal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("error creating journal directory: %v", err)
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if !resume {
+		flags |= os.O_TRUNC
+	} else if err := trimJournal(path); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening journal: %v", err)
+	}
+	return &Journal{f: f, path: path}, nil
+}
+
+// trimJournal cuts the journal at path back to the end of its last
+// complete record, so new records do not follow a line torn by a crash
+func trimJournal(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error opening journal: %v", err)
+	}
+	defer f.Close()
+
+	var good, size int64
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		size += int64(len(line))
+		if len(line) > 0 && line[len(line)-1] == '\n' && json.Valid(line) {
+			good = size
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error reading journal: %v", err)
+		}
+	}
+	if good == size {
+		return nil
+	}
+	logger.Warn("discarding torn journal record", "path", path, "bytes", size-good)
+	if err := os.Truncate(path, good); err != nil {
+		return fmt.Errorf("error trimming journal: %v", err)
+	}
+	return nil
+}
+
+// Path returns the journal's file name
+func (j *Journal) Path() string {
+	return j.path
+}
+
+// Write appends a record and syncs it to disk
+func (j *Journal) Write(r Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("error encoding journal record: %v", err)
+	}
+	data = append(data, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, err := j.f.Write(data); err != nil {
+		return fmt.Errorf("error writing journal: %v", err)
+	}
+	if err := j.f.Sync(); err != nil {
+		return fmt.Errorf("error syncing journal: %v", err)
+	}
+	return nil
+}
+
+// Close closes the journal file
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.f.Close()
+}
+
+// ReadJournal replays the journal at path and returns the last state of
+// play, with the latest deck and every logged event. It returns ErrNoGame
+// when there is no journal or its last game was finished. A torn final
+// line from a crash is ignored.
+func ReadJournal(path string) (*Record, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoGame
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening journal: %v", err)
+	}
+	defer f.Close()
+
+	var last *Record
+	var deck []Item
+	var cards []Card
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			// Only the last line can be torn; anything else is corruption
+			if scanner.Scan() {
+				return nil, fmt.Errorf("error reading journal line %d: %v", line, err)
+			}
+			break
+		}
+		if r.DeckChanged {
+			deck = r.Deck
+		}
+		if r.CardsChanged {
+			cards = r.Cards
+		}
+		events = append(events, r.Events...)
+		last = &r
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading journal: %v", err)
+	}
+
+	if last == nil || (last.State != Running && last.State != Paused) {
+		return nil, ErrNoGame
+	}
+	last.DeckChanged = true
+	last.Deck = deck
+	last.CardsChanged = true
+	last.Cards = cards
+	last.Events = events
+	return last, nil
+}
+
+// SetJournal makes the engine append a record to j after every change.
+// The journal is expected to be empty or to hold the game just restored.
+func (e *Engine) SetJournal(j *Journal) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.journal = j
+}
+
+// writeJournal appends the state of play to the journal. It must be called
+// with the lock held.
+func (e *Engine) writeJournal() {
+	if e.journal == nil {
+		return
+	}
+	r := Record{
+		Time:    time.Now(),
+		Seed:    e.seed,
+		Round:   e.round,
+		State:   e.state,
+		Loading: e.loading,
+		Next:    e.next,
+		Calls:   e.calls,
+		Pattern: e.pattern,
+		Claims:  e.claims,
+		Events:  e.events[e.journaled:],
+		Pending: e.pending,
+
+		AutoDaub: e.autoDaub,
+	}
+	if e.deckChanged {
+		r.DeckChanged = true
+		r.Deck = e.deck
+	}
+	if e.cardsChanged {
+		r.CardsChanged = true
+		r.Cards = e.cardList()
+	}
+	if err := e.journal.Write(r); err != nil {
+		logger.Error("game journal not saved", "path", e.journal.Path(), "err", err)
+		return
+	}
+	e.journaled = len(e.events)
+	e.deckChanged = false
+	e.cardsChanged = false
+}
+
+// Restore resumes the game in a record read from the journal. A game that
+// was running comes back paused, so the host can get their bearings
+// before calling resumes. A game saved while its deck was loading is still
+// loading: the rest of the deck follows through AddItems, until
+// FinishLoading is called.
+func (e *Engine) Restore(r *Record) {
+	e.mu.Lock()
+	e.seed = r.Seed
+	e.round = r.Round
+	e.state = r.State
+	if e.state == Running {
+		e.state = Paused
+	}
+	e.deck = append([]Item(nil), r.Deck...)
+	e.next = r.Next
+	e.calls = append([]Call(nil), r.Calls...)
+	e.pattern = r.Pattern
+	e.claims = append([]Claim(nil), r.Claims...)
+	e.cards = make(map[string]*Card, len(r.Cards))
+	for i := range r.Cards {
+		c := r.Cards[i]
+		e.cards[c.ID] = &c
+	}
+	e.pending = append([]PendingClaim(nil), r.Pending...)
+	e.autoDaub = r.AutoDaub
+	e.loading = r.Loading
+	e.auto = false
+	e.undo, e.redo = nil, nil
+	e.withdrawn = make(map[int]bool)
+
+	// Everything up to now is already in the journal
+	e.events = append([]Event(nil), r.Events...)
+	e.journaled = len(e.events)
+	e.deckChanged = false
+	e.cardsChanged = false
+	e.logEvent(EventRestore, len(e.calls), nil, fmt.Sprintf("Round %d resumed after %d calls", e.round, len(e.calls)))
+	e.schedule()
+	e.mu.Unlock()
+	e.notify()
+}
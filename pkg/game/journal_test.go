@@ -0,0 +1,105 @@
+package game
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// A game resumed after a crash that tore the journal's last line must
+// keep journalling readable records
+func TestJournalResumeAfterTornLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "game.journal")
+	items := []Item{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+
+	j, err := OpenJournal(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngine()
+	e.SetJournal(j)
+	e.NewGame(1, items, false)
+	if _, err := e.Call(); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	// A crash part way through writing a record
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"time":"2026-`)
+	f.Close()
+
+	r, err := ReadJournal(path)
+	if err != nil {
+		t.Fatalf("reading torn journal: %v", err)
+	}
+	j, err = OpenJournal(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e = NewEngine()
+	e.Restore(r)
+	e.SetJournal(j)
+	if err := e.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Call(); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	r, err = ReadJournal(path)
+	if err != nil {
+		t.Fatalf("reading resumed journal: %v", err)
+	}
+	if len(r.Calls) != 2 || r.State != Running {
+		t.Errorf("resumed journal has %d calls in state %s, want 2 calls running", len(r.Calls), r.State)
+	}
+}
+
+// A game saved while its deck was loading comes back still loading, so the
+// rest of the deck can join it
+func TestRestoreWhileLoading(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "game.journal")
+	j, err := OpenJournal(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngine()
+	e.SetJournal(j)
+	e.NewGame(1, []Item{{ID: "a"}}, true)
+	if _, err := e.Call(); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	r, err := ReadJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Loading {
+		t.Fatal("journal does not record that the deck was loading")
+	}
+	e = NewEngine()
+	e.Restore(r)
+	if err := e.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Call(); !errors.Is(err, ErrLoading) {
+		t.Fatalf("call past the restored deck returned %v, want ErrLoading", err)
+	}
+
+	e.AddItems(Item{ID: "b"})
+	e.FinishLoading()
+	call, err := e.Call()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if call.Number != 2 || call.Item.ID != "b" {
+		t.Errorf("call #%d of %s, want #2 of b", call.Number, call.Item.ID)
+	}
+}
@@ -0,0 +1,56 @@
+package game
+
+import "time"
+
+// EventKind names something that happened during a session
+type EventKind string
+
+const (
+	EventNewGame EventKind = "new_game"
+	EventCall    EventKind = "call"
+	EventPause   EventKind = "pause" // A bingo was claimed
+	EventResume  EventKind = "resume"
+	EventEnd     EventKind = "end"
+	EventUndo    EventKind = "undo" // A correction by the host
+	EventRedo    EventKind = "redo"
+	EventPattern EventKind = "pattern"
+	EventClaim   EventKind = "claim"   // A bingo was checked
+	EventRestore EventKind = "restore" // The game was resumed from the journal
+
+	EventClaimSubmitted EventKind = "claim_submitted" // A player sent a bingo from a digital card
+)
+
+// Event is one entry in the session's game log
+type Event struct {
+	Time   time.Time `json:"time"`
+	Kind   EventKind `json:"kind"`
+	Round  int       `json:"round"`          // The game the event belongs to, from 1
+	Call   int       `json:"call,omitempty"` // Number of the call concerned, if any
+	Item   *Item     `json:"item,omitempty"` // Item called, for call events
+	Detail string    `json:"detail"`         // Human-readable description
+
+	// Reverts is the kind of change an undo or redo applies to
+	Reverts EventKind `json:"reverts,omitempty"`
+	Pattern Pattern   `json:"pattern,omitempty"` // For pattern events
+	Claim   *Claim    `json:"claim,omitempty"`   // For claim events
+}
+
+// logEvent appends an event to the game log. It must be called with the
+// lock held.
+func (e *Engine) logEvent(kind EventKind, call int, item *Item, detail string) {
+	e.events = append(e.events, Event{
+		Time:   time.Now(),
+		Kind:   kind,
+		Round:  e.round,
+		Call:   call,
+		Item:   item,
+		Detail: detail,
+	})
+}
+
+// Log returns every event of the session so far, oldest first
+func (e *Engine) Log() []Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]Event(nil), e.events...)
+}
@@ -0,0 +1,119 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNothingToUndo is returned by Undo when no change can be undone
+	ErrNothingToUndo = errors.New("nothing to undo")
+	// ErrNothingToRedo is returned by Redo when no undone change remains
+	ErrNothingToRedo = errors.New("nothing to redo")
+)
+
+// memento is the state of play before or after an undoable change
+type memento struct {
+	kind   EventKind // The change: EventCall, EventPause, EventResume or EventEnd
+	action string    // Description of the change, e.g. "call #5 (Snowman)"
+	state  State
+	deck   []Item
+	next   int
+	calls  []Call
+	auto   bool // Automatic calling, which ending the game turns off
+}
+
+// save captures the state of play. It must be called with the lock held.
+func (e *Engine) save() memento {
+	return memento{
+		state: e.state,
+		deck:  e.deck,
+		next:  e.next,
+		calls: append([]Call(nil), e.calls...),
+		auto:  e.auto,
+	}
+}
+
+// record makes a change undoable, given the state from before it. It must
+// be called with the lock held.
+func (e *Engine) record(kind EventKind, action string, before memento) {
+	before.kind = kind
+	before.action = action
+	e.undo = append(e.undo, before)
+	e.redo = nil
+}
+
+// restore returns to a saved state of play. The deck and automatic
+// calling only change when ending the game or undoing the end, so items
+// added while loading and the host's auto-call choice are kept. It must be
+// called with the lock held.
+func (e *Engine) restore(m memento) {
+	if m.state == Ended || e.state == Ended {
+		e.deck = m.deck
+		e.deckChanged = true
+		e.auto = m.auto
+	}
+	e.state = m.state
+	e.next = m.next
+	e.calls = m.calls
+}
+
+// Undo reverts the most recent call, pause, resume or end of game. The
+// correction is recorded in the game log, and any call it withdraws is
+// marked as corrected when its number is called again.
+func (e *Engine) Undo() (Event, error) {
+	e.mu.Lock()
+	if len(e.undo) == 0 {
+		e.mu.Unlock()
+		return Event{}, ErrNothingToUndo
+	}
+	m := e.undo[len(e.undo)-1]
+	e.undo = e.undo[:len(e.undo)-1]
+
+	current := e.save()
+	current.kind, current.action = m.kind, m.action
+	e.redo = append(e.redo, current)
+
+	for n := len(m.calls) + 1; n <= len(e.calls); n++ {
+		e.withdrawn[n] = true
+	}
+	e.restore(m)
+	e.logEvent(EventUndo, len(e.calls), nil, fmt.Sprintf("Undid %s", m.action))
+	e.events[len(e.events)-1].Reverts = m.kind
+	event := e.events[len(e.events)-1]
+	e.schedule()
+	e.mu.Unlock()
+
+	e.notify()
+	return event, nil
+}
+
+// Redo repeats the most recently undone change
+func (e *Engine) Redo() (Event, error) {
+	e.mu.Lock()
+	if len(e.redo) == 0 {
+		e.mu.Unlock()
+		return Event{}, ErrNothingToRedo
+	}
+	m := e.redo[len(e.redo)-1]
+	e.redo = e.redo[:len(e.redo)-1]
+
+	current := e.save()
+	current.kind, current.action = m.kind, m.action
+	e.undo = append(e.undo, current)
+
+	e.restore(m)
+	var item *Item
+	if m.kind == EventCall && len(e.calls) > 0 {
+		redone := e.calls[len(e.calls)-1].Item
+		item = &redone
+	}
+	e.logEvent(EventRedo, len(e.calls), item, fmt.Sprintf("Redid %s", m.action))
+	e.events[len(e.events)-1].Reverts = m.kind
+	event := e.events[len(e.events)-1]
+	e.schedule()
+	e.mu.Unlock()
+
+	e.notify()
+	return event, nil
+}
@@ -0,0 +1,205 @@
+package game
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// play runs steps named call, pause, resume, end, undo and redo on e
+func play(t *testing.T, e *Engine, steps []string) {
+	t.Helper()
+	for i, step := range steps {
+		var err error
+		switch step {
+		case "call":
+			_, err = e.Call()
+		case "pause":
+			err = e.Pause()
+		case "resume":
+			err = e.Resume()
+		case "end":
+			e.End()
+		case "undo":
+			_, err = e.Undo()
+		case "redo":
+			_, err = e.Redo()
+		default:
+			t.Fatalf("unknown step %q", step)
+		}
+		if err != nil {
+			t.Fatalf("step %d (%s): %v", i+1, step, err)
+		}
+	}
+}
+
+// testItems returns a deck of n items with IDs a, b, c...
+func testItems(n int) []Item {
+	items := make([]Item, n)
+	for i := range items {
+		items[i] = Item{ID: string(rune('a' + i))}
+	}
+	return items
+}
+
+func TestUndoRedo(t *testing.T) {
+	tests := []struct {
+		name      string
+		steps     []string
+		calls     string // Item IDs called, in order
+		corrected []int  // Numbers of the calls marked corrected
+		state     State
+		deckSize  int
+		redo      string // RedoAction afterwards
+		summary   string // Item IDs in the round summary
+		ended     bool   // The round summary has an end time
+	}{
+		{
+			name:      "call again after undo",
+			steps:     []string{"call", "undo", "call"},
+			calls:     "a",
+			corrected: []int{1},
+			state:     Running,
+			deckSize:  5,
+			summary:   "a",
+		},
+		{
+			name:     "undo end",
+			steps:    []string{"call", "call", "end", "undo"},
+			calls:    "ab",
+			state:    Running,
+			deckSize: 5,
+			redo:     "end of game",
+			summary:  "ab",
+		},
+		{
+			name:    "redo end",
+			steps:   []string{"call", "end", "undo", "redo"},
+			state:   Ended,
+			summary: "a",
+			ended:   true,
+		},
+		{
+			name:     "undo pause",
+			steps:    []string{"call", "pause", "undo", "call"},
+			calls:    "ab",
+			state:    Running,
+			deckSize: 5,
+			summary:  "ab",
+		},
+		{
+			name:      "new call clears redo",
+			steps:     []string{"call", "call", "undo", "call"},
+			calls:     "ab",
+			corrected: []int{2},
+			state:     Running,
+			deckSize:  5,
+			summary:   "ab",
+		},
+		{
+			name:     "summary after undo and redo",
+			steps:    []string{"call", "call", "call", "undo", "undo", "redo"},
+			calls:    "ab",
+			state:    Running,
+			deckSize: 5,
+			redo:     "call #3 (c)",
+			summary:  "ab",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEngine()
+			e.NewGame(1, testItems(5), false)
+			play(t, e, tt.steps)
+			s := e.Snapshot()
+
+			calls, corrected := "", []int(nil)
+			for _, c := range s.Calls {
+				calls += c.Item.ID
+				if c.Corrected {
+					corrected = append(corrected, c.Number)
+				}
+			}
+			if calls != tt.calls {
+				t.Errorf("calls %q, want %q", calls, tt.calls)
+			}
+			if !reflect.DeepEqual(corrected, tt.corrected) {
+				t.Errorf("corrected calls %v, want %v", corrected, tt.corrected)
+			}
+			if s.State != tt.state {
+				t.Errorf("state %v, want %v", s.State, tt.state)
+			}
+			if s.DeckSize != tt.deckSize {
+				t.Errorf("deck size %d, want %d", s.DeckSize, tt.deckSize)
+			}
+			if s.RedoAction != tt.redo {
+				t.Errorf("redo action %q, want %q", s.RedoAction, tt.redo)
+			}
+			if tt.redo == "" {
+				if _, err := e.Redo(); !errors.Is(err, ErrNothingToRedo) {
+					t.Errorf("Redo returned %v, want ErrNothingToRedo", err)
+				}
+			}
+
+			rounds := Summarize(e.Log())
+			if len(rounds) != 1 {
+				t.Fatalf("%d rounds in summary, want 1", len(rounds))
+			}
+			summary := ""
+			for i, c := range rounds[0].Calls {
+				summary += c.Item.ID
+				if c.Number != i+1 {
+					t.Errorf("summary call %s numbered %d, want %d", c.Item.ID, c.Number, i+1)
+				}
+			}
+			if summary != tt.summary {
+				t.Errorf("summary calls %q, want %q", summary, tt.summary)
+			}
+			if ended := !rounds[0].Ended.IsZero(); ended != tt.ended {
+				t.Errorf("summary ended %v, want %v", ended, tt.ended)
+			}
+			if want := countSteps(tt.steps, "undo", "redo"); rounds[0].Corrections != want {
+				t.Errorf("%d corrections in summary, want %d", rounds[0].Corrections, want)
+			}
+		})
+	}
+}
+
+// countSteps counts the steps that are one of names
+func countSteps(steps []string, names ...string) int {
+	n := 0
+	for _, step := range steps {
+		for _, name := range names {
+			if step == name {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// Undoing a mistaken end of game turns automatic calling back on
+func TestUndoEndRestoresAutoCall(t *testing.T) {
+	e := NewEngine()
+	e.NewGame(1, testItems(5), false)
+	e.SetAutoInterval(time.Hour)
+	e.SetAutoCall(true)
+	play(t, e, []string{"call", "end"})
+	if e.Snapshot().AutoCall {
+		t.Fatal("auto-call still on after the end of the game")
+	}
+
+	play(t, e, []string{"undo"})
+	s := e.Snapshot()
+	if !s.AutoCall || s.NextAutoCall.IsZero() {
+		t.Errorf("auto-call %v with next call at %v after undoing the end, want it scheduled", s.AutoCall, s.NextAutoCall)
+	}
+
+	play(t, e, []string{"redo"})
+	if e.Snapshot().AutoCall {
+		t.Error("auto-call on after redoing the end")
+	}
+	e.SetAutoCall(false)
+}
@@ -0,0 +1,118 @@
+package imgcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// Cache stores processed versions of source images on disk. Entries are
+// keyed by a hash of the source file's content, so editing or replacing
+// a source image invalidates its cached versions automatically.
+type Cache struct {
+	dir string
+}
+
+// BuildFunc produces a processed image from the source file's content
+type BuildFunc func(src []byte) ([]byte, error)
+
+// New creates a cache in dir, creating the directory if needed
+func New(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %v", err)
+	}
+	return &Cache{dir: dir}, nil
+}
+
+// Dir returns the directory holding the cache
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// extensions maps sniffed MIME types to the extension of cache entries.
+// gofpdf picks the image decoder from the file extension, so it must match.
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// lookup returns the path of an existing entry for key, if there is one
+func (c *Cache) lookup(key string) (string, bool) {
+	for _, ext := range extensions {
+		path := filepath.Join(c.dir, key+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// Path returns the path of the cached variant of srcPath, building and
+// storing it first if it is not cached yet
+func (c *Cache) Path(srcPath, variant string, build BuildFunc) (string, error) {
+	src, err := os.ReadFile(srcPath)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(src)
+	key := hex.EncodeToString(sum[:]) + "-" + variant
+
+	if path, ok := c.lookup(key); ok {
+		return path, nil
+	}
+
+	data, err := build(src)
+	if err != nil {
+		return "", err
+	}
+	ext, ok := extensions[http.DetectContentType(data)]
+	if !ok {
+		ext = ".bin"
+	}
+
+	// Write to a temporary file first so a crash never leaves a partial entry
+	path := filepath.Join(c.dir, key+ext)
+	tmp, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to write cache entry: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write cache entry: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to write cache entry: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to write cache entry: %v", err)
+	}
+	return path, nil
+}
+
+// Get returns the cached variant of srcPath, building it if necessary
+func (c *Cache) Get(srcPath, variant string, build BuildFunc) ([]byte, error) {
+	path, err := c.Path(srcPath, variant, build)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+// Clear removes every cached image
+func (c *Cache) Clear() error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.Remove(filepath.Join(c.dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package imgproc
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/nfnt/resize"
+)
+
+// Crop describes how a non-square image is cut down to a square tile
+type Crop struct {
+	Mode string  // CropCenter, CropEntropy or CropFocus
+	X, Y float64 // Focus point for CropFocus, as fractions of width and height
+}
+
+// Crop modes
+const (
+	CropCenter  = "center"
+	CropEntropy = "entropy"
+	CropFocus   = "focus"
+)
+
+// ParseCrop parses a crop setting: "center", "entropy", or a focus point
+// given as "x,y" fractions of the image size, e.g. "0.5,0.25". An empty
+// string selects def.
+func ParseCrop(s string, def Crop) (Crop, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	switch s {
+	case "":
+		return def, nil
+	case CropCenter, "centre":
+		return Crop{Mode: CropCenter}, nil
+	case CropEntropy, "smart":
+		return Crop{Mode: CropEntropy}, nil
+	}
+
+	parts := strings.Split(s, ",")
+	if len(parts) == 2 {
+		x, errX := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+		y, errY := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if errX == nil && errY == nil && x >= 0 && x <= 1 && y >= 0 && y <= 1 {
+			return Crop{Mode: CropFocus, X: x, Y: y}, nil
+		}
+	}
+	return Crop{}, fmt.Errorf("invalid crop %q: want center, entropy or x,y between 0 and 1", s)
+}
+
+// String returns the crop in the form accepted by ParseCrop
+func (c Crop) String() string {
+	if c.Mode == CropFocus {
+		return fmt.Sprintf("%g,%g", c.X, c.Y)
+	}
+	if c.Mode == "" {
+		return CropCenter
+	}
+	return c.Mode
+}
+
+// SquareCrop returns the square part of img selected by c
+func SquareCrop(img image.Image, c Crop) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	side := w
+	if h < side {
+		side = h
+	}
+	if w == h {
+		return img
+	}
+
+	// Offset of the square along the long edge
+	slack := w - side
+	if h > w {
+		slack = h - side
+	}
+	var offset int
+	switch c.Mode {
+	case CropEntropy:
+		offset = entropyOffset(img, side)
+	case CropFocus:
+		// Centre the square on the focus point
+		if w > h {
+			offset = int(c.X*float64(w)) - side/2
+		} else {
+			offset = int(c.Y*float64(h)) - side/2
+		}
+	default:
+		offset = slack / 2
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > slack {
+		offset = slack
+	}
+
+	rect := image.Rect(b.Min.X, b.Min.Y, b.Min.X+side, b.Min.Y+side)
+	if w > h {
+		rect = rect.Add(image.Pt(offset, 0))
+	} else {
+		rect = rect.Add(image.Pt(0, offset))
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(dst, dst.Bounds(), img, rect.Min, draw.Src)
+	return dst
+}
+
+// entropyOffset finds the square window along the long edge of img whose
+// pixels carry the most information, measured as the Shannon entropy of
+// its luminance histogram. Plain backgrounds score low, so the window
+// settles on the subject of the picture.
+func entropyOffset(img image.Image, side int) int {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	// Work on a small copy; the heuristic does not need full resolution
+	const sample = 64
+	scale := float64(sample) / float64(side)
+	sw, sh := int(float64(w)*scale+0.5), int(float64(h)*scale+0.5)
+	if sw < 1 || sh < 1 {
+		return 0
+	}
+	small := resize.Resize(uint(sw), uint(sh), img, resize.Bilinear)
+
+	// Luminance of every sample pixel, quantised to 32 levels
+	lum := make([]uint8, sw*sh)
+	sb := small.Bounds()
+	for y := 0; y < sh; y++ {
+		for x := 0; x < sw; x++ {
+			r, g, bl, _ := small.At(sb.Min.X+x, sb.Min.Y+y).RGBA()
+			l := (299*r + 587*g + 114*bl) / 1000
+			lum[y*sw+x] = uint8(l >> 11)
+		}
+	}
+
+	window := sample
+	long := sw
+	if sh > sw {
+		long = sh
+	}
+	if window > long {
+		window = long
+	}
+
+	best, bestScore := 0, -1.0
+	for start := 0; start+window <= long; start++ {
+		var hist [32]int
+		n := 0
+		for a := start; a < start+window; a++ {
+			for c := 0; c < window && c < sw && c < sh; c++ {
+				var v uint8
+				if sw > sh {
+					v = lum[c*sw+a]
+				} else {
+					v = lum[a*sw+c]
+				}
+				hist[v]++
+				n++
+			}
+		}
+		score := entropy(hist[:], n)
+		// Prefer the centre when windows score the same
+		if score > bestScore+1e-9 || (math.Abs(score-bestScore) <= 1e-9 && absInt(start-(long-window)/2) < absInt(best-(long-window)/2)) {
+			best, bestScore = start, score
+		}
+	}
+	return int(float64(best) / scale)
+}
+
+// entropy returns the Shannon entropy, in bits, of a histogram with n samples
+func entropy(hist []int, n int) float64 {
+	if n == 0 {
+		return 0
+	}
+	var e float64
+	for _, count := range hist {
+		if count > 0 {
+			p := float64(count) / float64(n)
+			e -= p * math.Log2(p)
+		}
+	}
+	return e
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// Tile cuts the encoded image in data to a square using crop and scales it
+// to size pixels, ready to print on a card
+func Tile(data []byte, size int, crop Crop) ([]byte, error) {
+	img, format, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	square := SquareCrop(img, crop)
+	resized := resize.Resize(uint(size), uint(size), square, resize.Lanczos3)
+
+	var buf bytes.Buffer
+	if format == "jpeg" {
+		err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: 90})
+	} else {
+		err = png.Encode(&buf, toNRGBA(resized))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
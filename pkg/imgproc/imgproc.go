@@ -0,0 +1,72 @@
+package imgproc
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/nfnt/resize"
+)
+
+// ScreenSize is the longest edge, in pixels, of images shown by the caller
+const ScreenSize = 800
+
+// Version identifies the output of Decode, Optimize and Tile. It is part of
+// every cache key, so bump it whenever they start producing different
+// images, or copies made by older builds will keep being served.
+const Version = 2
+
+// Optimize shrinks the encoded image in data so its longest edge is maxSize
+// pixels, keeping the aspect ratio. JPEG photos stay JPEG; every other
+// format is encoded as PNG so transparency survives.
+func Optimize(data []byte, maxSize int) ([]byte, error) {
+	// Decode the image
+	img, format, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Calculate new size (max dimension of maxSize pixels while maintaining aspect ratio)
+	bounds := img.Bounds()
+	width := bounds.Dx()
+	height := bounds.Dy()
+	var newWidth, newHeight uint
+	if width > height {
+		newWidth = uint(maxSize)
+		newHeight = uint(float64(height) * (float64(maxSize) / float64(width)))
+	} else {
+		newHeight = uint(maxSize)
+		newWidth = uint(float64(width) * (float64(maxSize) / float64(height)))
+	}
+
+	// Resize the image
+	resized := resize.Resize(newWidth, newHeight, img, resize.Lanczos3)
+
+	// Encode the resized image
+	var buf bytes.Buffer
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: 85})
+	default:
+		// resize can return 16-bit images, which gofpdf cannot embed
+		err = png.Encode(&buf, toNRGBA(resized))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// toNRGBA converts img to 8 bits per channel
+func toNRGBA(img image.Image) *image.NRGBA {
+	if n, ok := img.(*image.NRGBA); ok {
+		return n
+	}
+	bounds := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+	return dst
+}
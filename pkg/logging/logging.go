@@ -0,0 +1,162 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Level is the severity of a log message
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String returns the name used for the level in log output
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return fmt.Sprintf("LEVEL(%d)", int(l))
+}
+
+var (
+	mu      sync.RWMutex
+	level   = LevelInfo
+	output  = log.New(os.Stderr, "", log.LstdFlags)
+	secrets []string
+)
+
+// SetLevel sets the minimum level that is written to the log
+func SetLevel(l Level) {
+	mu.Lock()
+	defer mu.Unlock()
+	level = l
+}
+
+// SetDebug enables or disables debug output, including wire dumps
+func SetDebug(debug bool) {
+	if debug {
+		SetLevel(LevelDebug)
+	} else {
+		SetLevel(LevelInfo)
+	}
+}
+
+// DebugEnabled reports whether debug messages are written
+func DebugEnabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return level <= LevelDebug
+}
+
+// SetOutput sets where log lines are written
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	output = log.New(w, "", log.LstdFlags)
+}
+
+// AddSecret registers a value, such as an API key, that must never appear
+// in the log. Registering a value again does nothing.
+func AddSecret(secret string) {
+	if secret == "" {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, s := range secrets {
+		if s == secret {
+			return
+		}
+	}
+	secrets = append(secrets, secret)
+}
+
+// sensitive matches credentials passed in URLs and headers
+var sensitive = regexp.MustCompile(`(?i)(client_id=|access_token=|api_key=|authorization:\s*(?:client-id|bearer)\s+)[^&\s"]+`)
+
+// Redact removes registered secrets and credential parameters from s
+func Redact(s string) string {
+	s = sensitive.ReplaceAllString(s, "${1}REDACTED")
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, "REDACTED")
+	}
+	return s
+}
+
+// Logger writes levelled, structured log lines for one component.
+// Messages take alternating key/value pairs, for example
+//
+//	logger.Info("image saved", "path", path, "bytes", n)
+type Logger struct {
+	component string
+}
+
+// New creates a logger for the named component
+func New(component string) *Logger {
+	return &Logger{component: component}
+}
+
+// Debug logs a message useful only when diagnosing problems
+func (l *Logger) Debug(msg string, kv ...interface{}) {
+	l.log(LevelDebug, msg, kv)
+}
+
+// Info logs a routine event
+func (l *Logger) Info(msg string, kv ...interface{}) {
+	l.log(LevelInfo, msg, kv)
+}
+
+// Warn logs a problem that was handled
+func (l *Logger) Warn(msg string, kv ...interface{}) {
+	l.log(LevelWarn, msg, kv)
+}
+
+// Error logs a failure
+func (l *Logger) Error(msg string, kv ...interface{}) {
+	l.log(LevelError, msg, kv)
+}
+
+func (l *Logger) log(lvl Level, msg string, kv []interface{}) {
+	mu.RLock()
+	enabled := lvl >= level
+	out := output
+	mu.RUnlock()
+	if !enabled {
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "level=%s component=%s msg=%q", lvl, l.component, msg)
+	for i := 0; i < len(kv); i += 2 {
+		key := fmt.Sprint(kv[i])
+		if i+1 >= len(kv) {
+			fmt.Fprintf(&b, " %s=MISSING", key)
+			break
+		}
+		value := fmt.Sprint(kv[i+1])
+		if strings.ContainsAny(value, " \t\n\"=") {
+			value = fmt.Sprintf("%q", value)
+		}
+		fmt.Fprintf(&b, " %s=%s", key, value)
+	}
+	out.Print(Redact(b.String()))
+}
@@ -0,0 +1,155 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// FetchOptions controls how Fetch pulls images from a provider
+type FetchOptions struct {
+	Count   int           // Number of images to fetch
+	Workers int           // Maximum number of concurrent requests
+	Timeout time.Duration // Time limit for a single request, 0 for none
+	Retries int           // Failed requests tolerated before giving up
+
+	// Save stores a fetched photo. It is called from the worker goroutines.
+	Save func(*Photo) error
+
+	// Progress, if set, is called after every attempt with the photo that
+	// was saved or the error that occurred.
+	Progress func(photo *Photo, err error)
+}
+
+// Failure records a request that did not produce a saved image
+type Failure struct {
+	Source string
+	Err    error
+}
+
+// Summary describes the outcome of a Fetch
+type Summary struct {
+	Saved     []*Photo
+	Skipped   []*Photo // Images already saved before
+	Failed    []Failure
+	Exhausted bool  // The provider ran out of images
+	Limited   bool  // The provider reached its request limit
+	Err       error // Why fetching stopped early, if it did
+}
+
+// Fetch pulls opts.Count images from p using a bounded pool of workers.
+// It stops early when ctx is cancelled, the provider is exhausted or at its
+// request limit, or more than opts.Retries requests have failed. Hitting the
+// limit is not a failure and does not count against the retries. An image
+// Save reports as a duplicate is skipped and another fetched in its place,
+// until the provider has repeated itself opts.Count times.
+func Fetch(ctx context.Context, p ImageProvider, opts FetchOptions) Summary {
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		summary  Summary
+		pending  = opts.Count // Images not yet claimed by a worker
+		failures int
+	)
+
+	// claim reserves one image for the calling worker
+	claim := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if pending <= 0 || ctx.Err() != nil {
+			return false
+		}
+		pending--
+		return true
+	}
+
+	// stop records why fetching ended early and cancels the other workers
+	stop := func(err error) {
+		if summary.Err == nil {
+			summary.Err = err
+		}
+		cancel()
+	}
+
+	fetchOne := func() (*Photo, error) {
+		reqCtx := ctx
+		if opts.Timeout > 0 {
+			var reqCancel context.CancelFunc
+			reqCtx, reqCancel = context.WithTimeout(ctx, opts.Timeout)
+			defer reqCancel()
+		}
+		photo, err := p.Next(reqCtx)
+		if err != nil {
+			return nil, err
+		}
+		if err := opts.Save(photo); err != nil {
+			return photo, err
+		}
+		return photo, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for claim() {
+				photo, err := fetchOne()
+				if opts.Progress != nil {
+					opts.Progress(photo, err)
+				}
+
+				mu.Lock()
+				switch {
+				case err == nil:
+					summary.Saved = append(summary.Saved, photo)
+				case errors.Is(err, io.EOF):
+					// Let requests already in flight finish, but claim no more
+					summary.Exhausted = true
+					pending = 0
+				case errors.Is(err, ErrDuplicate):
+					summary.Skipped = append(summary.Skipped, photo)
+					if len(summary.Skipped) > opts.Count {
+						stop(errors.New("provider keeps returning images already saved"))
+					} else {
+						pending++
+					}
+				case errors.Is(err, ErrLimit):
+					// No retry can succeed, so stop claiming like at the end
+					summary.Limited = true
+					pending = 0
+					if summary.Err == nil {
+						summary.Err = err
+					}
+				case ctx.Err() != nil:
+					stop(ctx.Err())
+				default:
+					source := p.Name()
+					if photo != nil {
+						source = photo.Source
+					}
+					summary.Failed = append(summary.Failed, Failure{Source: source, Err: err})
+					failures++
+					if failures > opts.Retries {
+						stop(fmt.Errorf("retry budget exhausted, last error: %v", err))
+					} else {
+						pending++ // Give the image back so another attempt is made
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return summary
+}
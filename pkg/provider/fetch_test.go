@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// limited serves photos until it reaches its request limit
+type limited struct {
+	mu    sync.Mutex
+	limit int
+	made  int
+}
+
+func (l *limited) Name() string { return "limited" }
+
+func (l *limited) Next(ctx context.Context) (*Photo, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.made >= l.limit {
+		return nil, fmt.Errorf("limited allows %d photos: %w", l.limit, ErrLimit)
+	}
+	l.made++
+	return &Photo{ID: fmt.Sprint(l.made)}, nil
+}
+
+// Reaching the request limit stops fetching without using up the retries
+func TestFetchStopsAtRequestLimit(t *testing.T) {
+	summary := Fetch(context.Background(), &limited{limit: 10}, FetchOptions{
+		Count:   24,
+		Workers: 4,
+		Retries: 5,
+		Save:    func(*Photo) error { return nil },
+	})
+	if len(summary.Saved) != 10 {
+		t.Errorf("saved %d photos, want 10", len(summary.Saved))
+	}
+	if len(summary.Failed) != 0 {
+		t.Errorf("%d requests counted as failed, want none", len(summary.Failed))
+	}
+	if !summary.Limited {
+		t.Error("summary does not report the request limit")
+	}
+}
+
+// repeating serves its photos in order, then io.EOF
+type repeating struct {
+	mu     sync.Mutex
+	photos []string
+}
+
+func (r *repeating) Name() string { return "repeating" }
+
+func (r *repeating) Next(ctx context.Context) (*Photo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.photos) == 0 {
+		return nil, io.EOF
+	}
+	id := r.photos[0]
+	r.photos = r.photos[1:]
+	return &Photo{ID: id, Data: []byte("\x89PNG\r\n\x1a\n" + id)}, nil
+}
+
+// An image already in the directory is skipped, not saved again, and
+// another is fetched in its place
+func TestFetchSkipsDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	summary := Fetch(context.Background(), &repeating{photos: []string{"a", "a", "b", "c"}}, FetchOptions{
+		Count: 2,
+		Save: func(p *Photo) error {
+			_, err := Save(dir, p)
+			return err
+		},
+	})
+	if len(summary.Saved) != 2 || len(summary.Skipped) != 1 || len(summary.Failed) != 0 {
+		t.Errorf("saved %d, skipped %d and failed %d, want 2, 1 and 0", len(summary.Saved), len(summary.Skipped), len(summary.Failed))
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), tempSuffix) {
+			t.Errorf("temporary file %s left behind", entry.Name())
+		}
+	}
+	if len(entries) != 2 {
+		t.Errorf("%d files saved, want 2", len(entries))
+	}
+}
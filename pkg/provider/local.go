@@ -0,0 +1,127 @@
+package provider
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"holidaybingo/pkg/imgproc"
+)
+
+// Dir serves the images found in a local directory, such as a network share
+type Dir struct {
+	mu    sync.Mutex
+	path  string
+	files []string
+	next  int
+}
+
+// NewDir creates a provider for the image files in dir
+func NewDir(dir string) (*Dir, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image directory: %v", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && imgproc.IsImageFile(entry.Name()) {
+			files = append(files, entry.Name())
+		}
+	}
+	return &Dir{path: dir, files: files}, nil
+}
+
+// Name returns the provider name
+func (d *Dir) Name() string {
+	return "dir"
+}
+
+// Next reads the next image file from the directory
+func (d *Dir) Next(ctx context.Context) (*Photo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	if d.next >= len(d.files) {
+		d.mu.Unlock()
+		return nil, io.EOF
+	}
+	name := d.files[d.next]
+	d.next++
+	d.mu.Unlock()
+
+	path := filepath.Join(d.path, name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Photo{ID: name, Source: path, Data: data}, nil
+}
+
+// Zip serves the images stored in a zip archive
+type Zip struct {
+	mu     sync.Mutex
+	reader *zip.ReadCloser
+	files  []*zip.File
+	next   int
+}
+
+// NewZip opens the archive at path. Close must be called when done.
+func NewZip(path string) (*Zip, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip archive: %v", err)
+	}
+
+	var files []*zip.File
+	for _, f := range r.File {
+		if !f.FileInfo().IsDir() && imgproc.IsImageFile(f.Name) {
+			files = append(files, f)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return &Zip{reader: r, files: files}, nil
+}
+
+// Name returns the provider name
+func (z *Zip) Name() string {
+	return "zip"
+}
+
+// Next extracts the next image from the archive
+func (z *Zip) Next(ctx context.Context) (*Photo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	z.mu.Lock()
+	if z.next >= len(z.files) {
+		z.mu.Unlock()
+		return nil, io.EOF
+	}
+	f := z.files[z.next]
+	z.next++
+	z.mu.Unlock()
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	return &Photo{ID: filepath.Base(f.Name), Source: f.Name, Data: data}, nil
+}
+
+// Close closes the underlying archive
+func (z *Zip) Close() error {
+	return z.reader.Close()
+}
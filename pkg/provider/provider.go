@@ -0,0 +1,130 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"holidaybingo/pkg/imgproc"
+)
+
+// Photo is a single image returned by a provider
+type Photo struct {
+	ID     string // Identifier of the image within its source
+	Source string // URL or path the image was read from
+	Data   []byte
+}
+
+// ErrLimit is returned, possibly wrapped, by a provider that has used up the
+// requests it is allowed to make
+var ErrLimit = errors.New("request limit reached")
+
+// ErrDuplicate is returned, wrapped, by Save for an image already in the
+// directory
+var ErrDuplicate = errors.New("image already saved")
+
+// tempSuffix marks a file still being written, so it is never mistaken for
+// an image, even if a crash leaves it behind
+const tempSuffix = ".part"
+
+// ImageProvider is a source of images for building a deck.
+// Next returns io.EOF once the source has no more images, and an error
+// wrapping ErrLimit once it may make no more requests. Implementations
+// must be safe for concurrent use, since Fetch calls Next from several workers.
+type ImageProvider interface {
+	Name() string
+	Next(ctx context.Context) (*Photo, error)
+}
+
+// extensions maps sniffed MIME types to the file extension we save them with
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+	"image/bmp":  ".bmp",
+}
+
+// fileName builds a collision-safe file name for the photo. It combines the
+// photo's ID, when it has one, with a hash of its content, so the same image
+// always maps to the same name and different images never share one.
+func fileName(photo *Photo, ext string) string {
+	sum := sha256.Sum256(photo.Data)
+	hash := hex.EncodeToString(sum[:])[:12]
+
+	base := strings.TrimSuffix(photo.ID, filepath.Ext(photo.ID))
+	base = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '-'
+	}, base)
+	base = strings.Trim(base, "-")
+	if len(base) > 48 {
+		base = base[:48]
+	}
+	if base == "" {
+		return hash + ext
+	}
+	return base + "-" + hash + ext
+}
+
+// Save writes the photo into dir and returns the path it was saved to.
+// The file is written to a temporary name and renamed into place, so
+// readers never see a partial image and concurrent saves cannot clash. An
+// image already saved under the same name is left alone and ErrDuplicate
+// returned with its path.
+func Save(dir string, photo *Photo) (string, error) {
+	mimeType := http.DetectContentType(photo.Data)
+	ext, ok := extensions[mimeType]
+	if !ok && imgproc.IsSVG(photo.Data) {
+		ext, ok = ".svg", true
+	}
+	if !ok {
+		return "", fmt.Errorf("unsupported image type %s", mimeType)
+	}
+
+	// Ensure the directory exists
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create image directory: %v", err)
+	}
+
+	imgPath := filepath.Join(dir, fileName(photo, ext))
+	if _, err := os.Stat(imgPath); err == nil {
+		return imgPath, fmt.Errorf("%s: %w", filepath.Base(imgPath), ErrDuplicate)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".tmp-*"+tempSuffix)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once the rename has succeeded
+
+	if _, err := tmp.Write(photo.Data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write image: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write image: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to write image: %v", err)
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(tmpPath, imgPath); err != nil {
+		return "", fmt.Errorf("failed to save image: %v", err)
+	}
+	return imgPath, nil
+}
@@ -0,0 +1,197 @@
+package provider
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// download fetches the body at rawURL
+func download(ctx context.Context, client *http.Client, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// idFromURL uses the last path element of a URL as the image ID
+func idFromURL(rawURL string) string {
+	trimmed := rawURL
+	if i := strings.IndexAny(trimmed, "?#"); i >= 0 {
+		trimmed = trimmed[:i]
+	}
+	return path.Base(trimmed)
+}
+
+// URLList downloads images from a fixed list of URLs
+type URLList struct {
+	Client *http.Client
+	mu     sync.Mutex
+	urls   []string
+	next   int
+}
+
+// NewURLList creates a provider for the given image URLs
+func NewURLList(urls []string) *URLList {
+	return &URLList{Client: http.DefaultClient, urls: urls}
+}
+
+// LoadURLList reads image URLs from a file, one per line.
+// Blank lines and lines starting with # are ignored.
+func LoadURLList(filename string) (*URLList, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open URL list: %v", err)
+	}
+	defer f.Close()
+
+	var urls []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read URL list: %v", err)
+	}
+	return NewURLList(urls), nil
+}
+
+// Name returns the provider name
+func (l *URLList) Name() string {
+	return "urls"
+}
+
+// Next downloads the next URL in the list
+func (l *URLList) Next(ctx context.Context) (*Photo, error) {
+	l.mu.Lock()
+	if l.next >= len(l.urls) {
+		l.mu.Unlock()
+		return nil, io.EOF
+	}
+	rawURL := l.urls[l.next]
+	l.next++
+	l.mu.Unlock()
+
+	data, err := download(ctx, l.Client, rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %v", rawURL, err)
+	}
+	return &Photo{ID: idFromURL(rawURL), Source: rawURL, Data: data}, nil
+}
+
+// JSONAPI reads a list of images from a JSON endpoint. The endpoint must
+// return an array whose elements are either URL strings or objects holding
+// the URL in Field, and optionally an identifier in IDField.
+type JSONAPI struct {
+	Client   *http.Client
+	Endpoint string
+	Field    string
+	IDField  string
+
+	mu     sync.Mutex
+	items  []Photo
+	loaded bool
+	next   int
+}
+
+// NewJSONAPI creates a provider for the given endpoint, reading image URLs from field
+func NewJSONAPI(endpoint, field string) *JSONAPI {
+	if field == "" {
+		field = "url"
+	}
+	return &JSONAPI{
+		Client:   http.DefaultClient,
+		Endpoint: endpoint,
+		Field:    field,
+		IDField:  "id",
+	}
+}
+
+// Name returns the provider name
+func (a *JSONAPI) Name() string {
+	return "json"
+}
+
+// load fetches and parses the image list from the endpoint
+func (a *JSONAPI) load(ctx context.Context) error {
+	body, err := download(ctx, a.Client, a.Endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to query %s: %v", a.Endpoint, err)
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return fmt.Errorf("Error parsing JSON: %v", err)
+	}
+
+	for _, elem := range raw {
+		var u string
+		if err := json.Unmarshal(elem, &u); err == nil {
+			a.items = append(a.items, Photo{ID: idFromURL(u), Source: u})
+			continue
+		}
+
+		var obj map[string]interface{}
+		if err := json.Unmarshal(elem, &obj); err != nil {
+			return fmt.Errorf("unexpected element in image list: %s", elem)
+		}
+		u, _ = obj[a.Field].(string)
+		if u == "" {
+			return fmt.Errorf("image list element has no %q field", a.Field)
+		}
+		id := idFromURL(u)
+		if v, ok := obj[a.IDField]; ok {
+			id = fmt.Sprint(v)
+		}
+		a.items = append(a.items, Photo{ID: id, Source: u})
+	}
+	a.loaded = true
+	return nil
+}
+
+// Next downloads the next image listed by the endpoint. The list itself is
+// fetched on the first call.
+func (a *JSONAPI) Next(ctx context.Context) (*Photo, error) {
+	a.mu.Lock()
+	if !a.loaded {
+		if err := a.load(ctx); err != nil {
+			a.mu.Unlock()
+			return nil, err
+		}
+	}
+	if a.next >= len(a.items) {
+		a.mu.Unlock()
+		return nil, io.EOF
+	}
+	item := a.items[a.next]
+	a.next++
+	a.mu.Unlock()
+
+	data, err := download(ctx, a.Client, item.Source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %v", item.Source, err)
+	}
+	item.Data = data
+	return &item, nil
+}
@@ -1,14 +1,209 @@
 package secrets
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
 	"github.com/joho/godotenv"
-	"log"
 )
 
+// ErrNotFound is returned when a provider does not hold the requested secret
+var ErrNotFound = errors.New("secret not found")
+
+// Provider looks up secrets, such as API keys, by name
+type Provider interface {
+	Get(name string) (string, error)
+}
+
 // LoadEnv loads environment variables from a .env file
-func LoadEnv() {
-	err := godotenv.Load()
+func LoadEnv() error {
+	if err := godotenv.Load(); err != nil {
+		return fmt.Errorf("error loading .env file: %v", err)
+	}
+	return nil
+}
+
+// Env reads secrets from environment variables
+type Env struct{}
+
+// Get returns the value of the environment variable name
+func (Env) Get(name string) (string, error) {
+	if v := os.Getenv(name); v != "" {
+		return v, nil
+	}
+	return "", ErrNotFound
+}
+
+// DotEnv reads secrets from a .env file without changing the environment
+type DotEnv struct {
+	Path string
+}
+
+// Get returns the value of name in the .env file. A missing file is
+// treated the same as a missing entry.
+func (d DotEnv) Get(name string) (string, error) {
+	values, err := godotenv.Read(d.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("error loading %s: %v", d.Path, err)
+	}
+	if v := values[name]; v != "" {
+		return v, nil
+	}
+	return "", ErrNotFound
+}
+
+// FileStore keeps secrets in a JSON file readable only by the current
+// user, in the manner of an OS keyring
+type FileStore struct {
+	Path string
+}
+
+// DefaultFileStore returns the store in the user's configuration directory
+func DefaultFileStore() (*FileStore, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate config directory: %v", err)
+	}
+	return &FileStore{Path: filepath.Join(dir, "holidaybingo", "secrets.json")}, nil
+}
+
+// load reads the store, refusing files that other users could read
+func (s *FileStore) load() (map[string]string, error) {
+	info, err := os.Stat(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("%s is accessible by other users, run: chmod 600 %s", s.Path, s.Path)
+	}
+
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", s.Path, err)
+	}
+	return values, nil
+}
+
+// Get returns the stored value of name
+func (s *FileStore) Get(name string) (string, error) {
+	values, err := s.load()
 	if err != nil {
-		log.Fatalf("Error loading .env file")
+		return "", err
+	}
+	if v := values[name]; v != "" {
+		return v, nil
+	}
+	return "", ErrNotFound
+}
+
+// Set stores value under name, creating the store with 0600 permissions
+func (s *FileStore) Set(name, value string) error {
+	values, err := s.load()
+	if err != nil {
+		return err
+	}
+	values[name] = value
+
+	data, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0700); err != nil {
+		return fmt.Errorf("failed to create secret store directory: %v", err)
+	}
+
+	tmpPath := s.Path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write secret store: %v", err)
+	}
+	if err := os.Rename(tmpPath, s.Path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write secret store: %v", err)
+	}
+	return nil
+}
+
+// Prompt asks for secrets on the command line. If Store is set, the
+// value entered is saved there so the user is only asked once.
+type Prompt struct {
+	In    io.Reader
+	Out   io.Writer
+	Store *FileStore
+}
+
+// Get asks the user to enter name
+func (p Prompt) Get(name string) (string, error) {
+	in, out := p.In, p.Out
+	if in == nil {
+		in = os.Stdin
+	}
+	if out == nil {
+		out = os.Stderr
+	}
+
+	fmt.Fprintf(out, "Enter %s: ", name)
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	value := strings.TrimSpace(line)
+	if value == "" {
+		return "", ErrNotFound
+	}
+
+	if p.Store != nil {
+		if err := p.Store.Set(name, value); err != nil {
+			return "", err
+		}
+		fmt.Fprintf(out, "Saved %s to %s\n", name, p.Store.Path)
+	}
+	return value, nil
+}
+
+// Chain tries each provider in turn and returns the first value found
+type Chain []Provider
+
+// Get returns name from the first provider that has it
+func (c Chain) Get(name string) (string, error) {
+	for _, p := range c {
+		v, err := p.Get(name)
+		if err == nil {
+			return v, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("%s: %w", name, ErrNotFound)
+}
+
+// Default returns the usual lookup order: the environment, then .env,
+// then the user's secret store. When interactive is true the user is
+// prompted as a last resort and the answer is kept in the store.
+func Default(interactive bool) Chain {
+	chain := Chain{Env{}, DotEnv{Path: ".env"}}
+	store, err := DefaultFileStore()
+	if err == nil {
+		chain = append(chain, store)
+	}
+	if interactive {
+		chain = append(chain, Prompt{Store: store})
 	}
+	return chain
 }
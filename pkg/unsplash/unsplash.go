@@ -1,115 +1,239 @@
 package unsplash
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
+	"net/http/httputil"
 	"net/url"
 	"os"
+	"strconv"
+	"sync"
+
+	"holidaybingo/pkg/logging"
+	"holidaybingo/pkg/provider"
+)
+
+var logger = logging.New("unsplash")
+
+var (
+	requestMu    sync.Mutex
+	requestCount int = 0
 )
 
-var requestCount int = 0
 const maxRequests int = 10
 
+// DefaultImageSize is the edge length, in pixels, of the square images we request
+const DefaultImageSize = 800
+
+var imageSize = DefaultImageSize
+
+// SetImageSize sets the edge length, in pixels, of downloaded images
+func SetImageSize(size int) {
+	if size > 0 {
+		imageSize = size
+	}
+}
+
 // CanMakeRequest checks if the request limit has been reached
 func CanMakeRequest() bool {
-    return requestCount < maxRequests
+	requestMu.Lock()
+	defer requestMu.Unlock()
+	return requestCount < maxRequests
 }
 
 // IncrementRequestCount increments the request counter
 func IncrementRequestCount() {
-    requestCount++
+	requestMu.Lock()
+	defer requestMu.Unlock()
+	requestCount++
+}
+
+// reserveRequest claims one request from the limit, so that concurrent
+// fetches cannot overshoot it. The claim is returned with releaseRequest
+// if the fetch fails.
+func reserveRequest() bool {
+	requestMu.Lock()
+	defer requestMu.Unlock()
+	if requestCount >= maxRequests {
+		return false
+	}
+	requestCount++
+	return true
+}
+
+// releaseRequest returns a claim made by reserveRequest
+func releaseRequest() {
+	requestMu.Lock()
+	defer requestMu.Unlock()
+	requestCount--
 }
 
 // Struct to parse the JSON response from Unsplash
 type UnsplashResponse struct {
+	ID   string `json:"id"`
 	Urls struct {
-		Full string `json:"full"`
+		Raw     string `json:"raw"`
+		Full    string `json:"full"`
+		Regular string `json:"regular"`
 	} `json:"urls"`
 }
 
-// GetPhoto fetches a photo from Unsplash
+// sizedURL returns the URL of a square, cropped variant of the photo at the
+// configured resolution. Unsplash's raw URL accepts imgix resize parameters,
+// so we ask for the size we need instead of downloading the original.
+func sizedURL(resp UnsplashResponse, size int) (string, error) {
+	if resp.Urls.Raw == "" {
+		if resp.Urls.Regular != "" {
+			return resp.Urls.Regular, nil
+		}
+		if resp.Urls.Full != "" {
+			return resp.Urls.Full, nil
+		}
+		return "", fmt.Errorf("response contains no image URL")
+	}
+
+	u, err := url.Parse(resp.Urls.Raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid raw URL: %v", err)
+	}
+	q := u.Query()
+	q.Set("w", strconv.Itoa(size))
+	q.Set("h", strconv.Itoa(size))
+	q.Set("fit", "crop")
+	q.Set("crop", "entropy")
+	q.Set("fm", "jpg")
+	q.Set("q", "80")
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// get issues a GET request that is cancelled along with ctx. If apiKey is
+// set it is sent in the Authorization header, never in the URL.
+func get(ctx context.Context, rawURL, apiKey string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept-Version", "v1")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Client-ID "+apiKey)
+	}
+	if logging.DebugEnabled() {
+		if dump, err := httputil.DumpRequestOut(req, false); err == nil {
+			logger.Debug("wire dump", "request", string(dump))
+		}
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if urlErr, ok := err.(*url.Error); ok {
+		// Keep credentials in the query string out of error messages
+		urlErr.URL = logging.Redact(urlErr.URL)
+	}
+	return resp, err
+}
+
+// ErrRequestLimit is returned once maxRequests photos have been fetched
+var ErrRequestLimit = fmt.Errorf("Unsplash allows %d photos per run: %w", maxRequests, provider.ErrLimit)
+
+// APIKeyName is the name under which the Unsplash access key is stored
+const APIKeyName = "UNSPLASH_API_KEY"
+
+// Provider serves random photos from Unsplash
+type Provider struct {
+	APIKey string
+}
+
+// Name returns the provider name
+func (Provider) Name() string {
+	return "unsplash"
+}
+
+// Next fetches a random photo from Unsplash
+func (p Provider) Next(ctx context.Context) (*provider.Photo, error) {
+	id, data, err := getPhoto(ctx, p.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	return &provider.Photo{ID: id, Source: "unsplash:" + id, Data: data}, nil
+}
+
+// GetPhoto fetches a photo from Unsplash using the key in the environment
 func GetPhoto() ([]byte, error) {
-	if !CanMakeRequest() {
-		return nil, fmt.Errorf("Request limit reached")
+	_, data, err := getPhoto(context.Background(), os.Getenv(APIKeyName))
+	return data, err
+}
+
+// getPhoto fetches a photo from Unsplash and returns its Unsplash ID along with the image data
+func getPhoto(ctx context.Context, apiKey string) (id string, data []byte, err error) {
+	if !reserveRequest() {
+		return "", nil, ErrRequestLimit
 	}
+	defer func() {
+		if err != nil {
+			releaseRequest()
+		}
+	}()
 
-	apiKey := os.Getenv("UNSPLASH_API_KEY")
 	if apiKey == "" {
-		return nil, fmt.Errorf("Unsplash API key not set")
+		return "", nil, fmt.Errorf("Unsplash API key not set")
 	}
+	logging.AddSecret(apiKey)
 
 	// TODO: Refine query keywords for better matching of winter holiday themes and clip art
 	// Further refined query to focus on winter holiday themes and clip art
 	query := url.QueryEscape("Christmas tree clip art,Hanukkah menorah illustration,Kwanzaa candles art,holiday decorations graphic")
-	url := fmt.Sprintf("https://api.unsplash.com/photos/random?query=%s&client_id=%s&orientation=squarish", query, apiKey)
-	resp, err := http.Get(url)
+	url := fmt.Sprintf("https://api.unsplash.com/photos/random?query=%s&orientation=squarish", query)
+	resp, err := get(ctx, url, apiKey)
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 	defer resp.Body.Close()
 
-	// Log HTTP status and headers
-	log.Printf("HTTP Status: %s", resp.Status)
-	for key, values := range resp.Header {
-		for _, value := range values {
-			log.Printf("Header: %s: %s", key, value)
+	logger.Debug("API response", "status", resp.Status)
+	if logging.DebugEnabled() {
+		if dump, err := httputil.DumpResponse(resp, false); err == nil {
+			logger.Debug("wire dump", "response", string(dump))
 		}
 	}
 
-	// Read and log the response body for debugging
 	respBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return "", nil, err
+	}
+	logger.Debug("response body", "body", string(respBody))
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("Unsplash API returned %s", resp.Status)
 	}
-	log.Printf("Response Body: %s", respBody)
 
 	// Parse the JSON response
 	var unsplashResp UnsplashResponse
 	if err := json.Unmarshal(respBody, &unsplashResp); err != nil {
-		return nil, fmt.Errorf("Error parsing JSON: %v", err)
+		return "", nil, fmt.Errorf("Error parsing JSON: %v", err)
 	}
 
-	// Fetch the actual image
-	imageResp, err := http.Get(unsplashResp.Urls.Full)
+	// Fetch a square variant at the configured size rather than the original
+	imageURL, err := sizedURL(unsplashResp, imageSize)
 	if err != nil {
-		return nil, err
+		return "", nil, err
+	}
+	imageResp, err := get(ctx, imageURL, "")
+	if err != nil {
+		return "", nil, err
 	}
 	defer imageResp.Body.Close()
+	if imageResp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("image download returned %s", imageResp.Status)
+	}
 
 	// Read the image data
 	imageData, err := ioutil.ReadAll(imageResp.Body)
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 
-	IncrementRequestCount()
-	return imageData, nil
-}
-
-// SavePhoto saves the photo to the img directory with a sequential name
-func SavePhoto(photoData []byte) error {
-	imgDir := "img"
-	// Ensure the img directory exists
-	if _, err := os.Stat(imgDir); os.IsNotExist(err) {
-		os.Mkdir(imgDir, os.ModePerm)
-	}
-
-	// Find the next available image filename
-	var imgPath string
-	for i := 1; ; i++ {
-		imgPath = fmt.Sprintf("%s/img%d.jpg", imgDir, i)
-		if _, err := os.Stat(imgPath); os.IsNotExist(err) {
-			break
-		}
-	}
-
-	// Save the image
-	err := os.WriteFile(imgPath, photoData, 0644)
-	if err != nil {
-		return err
-	}
-	return nil
+	logger.Info("photo fetched", "id", unsplashResp.ID, "bytes", len(imageData))
+	return unsplashResp.ID, imageData, nil
 }
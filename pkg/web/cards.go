@@ -0,0 +1,341 @@
+package web
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"holidaybingo/pkg/game"
+)
+
+// maxPlayerName is the longest player name kept, in characters
+const maxPlayerName = 40
+
+// tokenHeader carries a card's token on requests that change the card
+const tokenHeader = "X-Card-Token"
+
+// Dealer supplies the cards players join with
+type Dealer interface {
+	// Deal returns a new card with squares drawn from the deck
+	Deal() (game.Card, error)
+	// Printed returns the printed card with the given ID
+	Printed(id string) (game.Card, bool)
+	// Page returns the HTML page for card; image returns the URL of the
+	// image on a square
+	Page(card game.Card, image func(square int) string) (string, error)
+}
+
+// CardView is a player's card as sent to their browser
+type CardView struct {
+	ID      string                 `json:"id"`
+	Player  string                 `json:"player"`
+	Marked  [game.CardSquares]bool `json:"marked"`
+	Claimed bool                   `json:"claimed"` // A claim is waiting for the host
+}
+
+// joinResponse hands a player their card and the token that lets them
+// mark it and claim on it
+type joinResponse struct {
+	CardView
+	Token string `json:"token"`
+}
+
+// joinRequest is sent by a player joining the game
+type joinRequest struct {
+	Player string `json:"player"`
+	CardID string `json:"card_id"` // A printed card to play; empty for a new card
+	Token  string `json:"token"`   // Token from an earlier join, to rejoin with a card in play
+}
+
+// markRequest marks or unmarks a square
+type markRequest struct {
+	Square int  `json:"square"`
+	Marked bool `json:"marked"`
+}
+
+// SetDealer lets players join with digital cards from d
+func (s *Server) SetDealer(d Dealer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dealer = d
+}
+
+// getDealer returns the dealer, or nil if players cannot join
+func (s *Server) getDealer() Dealer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dealer
+}
+
+// grant issues a new token for a card, replacing any earlier one
+func (s *Server) grant(id string) string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("cannot make card token: %v", err))
+	}
+	token := hex.EncodeToString(b)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[id] = token
+	return token
+}
+
+// owns reports whether token is the one issued for a card
+func (s *Server) owns(id, token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	want, ok := s.tokens[id]
+	return ok && subtle.ConstantTimeCompare([]byte(want), []byte(token)) == 1
+}
+
+// rejoin returns the token for a player rejoining with a card in play. The
+// player must hold the card's token; tokens do not outlive the caller app,
+// so for a card restored from the journal the player's name will do.
+func (s *Server) rejoin(card game.Card, player, token string) (string, bool) {
+	if s.owns(card.ID, token) {
+		return token, true
+	}
+	s.mu.Lock()
+	_, issued := s.tokens[card.ID]
+	s.mu.Unlock()
+	if issued || !strings.EqualFold(card.Player, player) {
+		return "", false
+	}
+	return s.grant(card.ID), true
+}
+
+// view returns what a player's browser is told about card now
+func (s *Server) view(card game.Card) CardView {
+	return cardView(card, s.engine.Snapshot().Pending)
+}
+
+// cardView returns what a player's browser is told about card, given the
+// claims waiting for the host
+func cardView(card game.Card, pending []game.PendingClaim) CardView {
+	v := CardView{ID: card.ID, Player: card.Player, Marked: card.Marked}
+	for _, p := range pending {
+		if p.CardID == card.ID {
+			v.Claimed = true
+		}
+	}
+	return v
+}
+
+// handleJoin hands a player a card: the printed card they asked for, or a
+// new one. A player rejoining with a card already in play gets it back
+// with its marks.
+func (s *Server) handleJoin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	dealer := s.getDealer()
+	if dealer == nil {
+		http.Error(w, "digital cards are not available", http.StatusServiceUnavailable)
+		return
+	}
+	var req joinRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	player := strings.TrimSpace(req.Player)
+	if player == "" {
+		http.Error(w, "enter your name to join", http.StatusBadRequest)
+		return
+	}
+	if runes := []rune(player); len(runes) > maxPlayerName {
+		player = string(runes[:maxPlayerName])
+	}
+
+	id := strings.ToUpper(strings.TrimSpace(req.CardID))
+	if id != "" {
+		if card, ok := s.engine.Card(id); ok {
+			token, ok := s.rejoin(card, player, req.Token)
+			if !ok {
+				logger.Warn("refused rejoin", "card", id, "player", player)
+				http.Error(w, "Card "+id+" is already being played.", http.StatusForbidden)
+				return
+			}
+			logger.Info("player rejoined", "card", id, "player", card.Player)
+			writeJSON(w, http.StatusOK, joinResponse{s.view(card), token})
+			return
+		}
+		card, ok := dealer.Printed(id)
+		if !ok {
+			http.Error(w, "there is no card "+id, http.StatusNotFound)
+			return
+		}
+		card.Player = player
+		s.join(w, card)
+		return
+	}
+
+	// New card IDs are random, so deal again on the rare clash
+	for tries := 0; tries < 5; tries++ {
+		card, err := dealer.Deal()
+		if err != nil {
+			logger.Error("cannot deal card", "err", err)
+			http.Error(w, "no cards can be dealt right now", http.StatusServiceUnavailable)
+			return
+		}
+		if _, taken := s.engine.Card(card.ID); taken {
+			continue
+		}
+		card.Player = player
+		s.join(w, card)
+		return
+	}
+	http.Error(w, "no cards can be dealt right now", http.StatusServiceUnavailable)
+}
+
+// join puts card in play and returns it to the player
+func (s *Server) join(w http.ResponseWriter, card game.Card) {
+	if err := s.engine.AddCard(card); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	token := s.grant(card.ID)
+	card, _ = s.engine.Card(card.ID)
+	writeJSON(w, http.StatusCreated, joinResponse{s.view(card), token})
+}
+
+// handleCardAPI serves a card's state, and takes marks and bingo claims
+// from the player holding the card's token:
+//
+//	GET  /api/cards/<id>
+//	POST /api/cards/<id>/marks
+//	POST /api/cards/<id>/claim
+func (s *Server) handleCardAPI(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/cards/"), "/")
+	card, ok := s.engine.Card(parts[0])
+	if !ok || len(parts) > 2 {
+		http.NotFound(w, r)
+		return
+	}
+
+	action := ""
+	if len(parts) == 2 {
+		action = parts[1]
+	}
+	if r.Method == http.MethodPost && !s.owns(card.ID, r.Header.Get(tokenHeader)) {
+		http.Error(w, "This card is being played elsewhere. Rejoin with its card ID to play it here.", http.StatusForbidden)
+		return
+	}
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.view(card))
+	case action == "marks" && r.Method == http.MethodPost:
+		var req markRequest
+		if !readJSON(w, r, &req) {
+			return
+		}
+		card, err := s.engine.Mark(card.ID, req.Square, req.Marked)
+		if errors.Is(err, game.ErrNotCalled) {
+			http.Error(w, "That square hasn't been called yet.", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusOK, s.view(card))
+	case action == "claim" && r.Method == http.MethodPost:
+		claim, err := s.engine.SubmitClaim(card.ID)
+		switch {
+		case errors.Is(err, game.ErrNoBingo):
+			logger.Info("false claim", "card", card.ID, "player", card.Player)
+			http.Error(w, "Not a bingo yet: your called squares don't make a "+s.engine.Snapshot().Pattern.String()+".", http.StatusConflict)
+			return
+		case errors.Is(err, game.ErrNotRunning):
+			http.Error(w, "There is no game in play to claim.", http.StatusConflict)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		logger.Info("bingo claimed", "card", claim.CardID, "player", claim.Player)
+		writeJSON(w, http.StatusAccepted, s.view(card))
+	case action == "" || action == "marks" || action == "claim":
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handleCardPage serves a player's card, rendered from the card template,
+// and the images on its squares:
+//
+//	/cards/<id>
+//	/cards/<id>/squares/<n>
+func (s *Server) handleCardPage(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/cards/"), "/")
+	card, ok := s.engine.Card(parts[0])
+	dealer := s.getDealer()
+	if !ok || dealer == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case len(parts) == 1:
+		page, err := dealer.Page(card, func(square int) string {
+			return "/cards/" + card.ID + "/squares/" + strconv.Itoa(square)
+		})
+		if err != nil {
+			logger.Error("cannot render card", "card", card.ID, "err", err)
+			http.Error(w, "cannot show this card", http.StatusInternalServerError)
+			return
+		}
+		page = strings.Replace(page, "</head>", `<link rel="stylesheet" href="/card.css">`+"\n</head>", 1)
+		page = strings.Replace(page, "</body>", `<script src="/card.js"></script>`+"\n</body>", 1)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write([]byte(page))
+
+	case len(parts) == 3 && parts[1] == "squares":
+		// Players only get the images on their own card
+		square, err := strconv.Atoi(parts[2])
+		if err != nil || square < 0 || square >= game.CardSquares || card.Squares[square] == "" {
+			http.NotFound(w, r)
+			return
+		}
+		data, ok := s.images(game.Item{ID: card.Squares[square]})
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", http.DetectContentType(data))
+		w.Header().Set("Cache-Control", "private, max-age=3600")
+		w.Write(data)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// readJSON decodes a small request body into v, answering the request
+// itself if it cannot
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, 4096)
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// writeJSON sends v as the response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Debug("cannot send response", "err", err)
+	}
+}
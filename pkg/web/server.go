@@ -0,0 +1,275 @@
+// Package web serves a live caller board and digital player cards to
+// browsers, driven by the same game engine as the caller app
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"holidaybingo/pkg/game"
+	"holidaybingo/pkg/logging"
+	"holidaybingo/ui"
+)
+
+var logger = logging.New("web")
+
+// keepAlive is how often an idle event stream gets a comment, so proxies
+// do not close it
+const keepAlive = 25 * time.Second
+
+// ImageSource returns the screen-sized image of an item
+type ImageSource func(item game.Item) ([]byte, bool)
+
+// BoardCall is a call as shown on the board
+type BoardCall struct {
+	Number    int       `json:"number"`
+	ID        string    `json:"id"`
+	Caption   string    `json:"caption"`
+	Time      time.Time `json:"time"`
+	Corrected bool      `json:"corrected,omitempty"`
+}
+
+// Board is the state sent to browsers. It only describes calls already
+// made, never the rest of the deck.
+type Board struct {
+	State   string      `json:"state"`
+	Round   int         `json:"round"`
+	Pattern string      `json:"pattern"`
+	Calls   []BoardCall `json:"calls"`
+}
+
+// Server serves the caller board and streams changes to it over
+// Server-Sent Events
+type Server struct {
+	engine *game.Engine
+	images ImageSource
+
+	mu      sync.Mutex
+	dealer  Dealer            // Deals digital cards; nil until players may join
+	board   []byte            // Latest board as JSON
+	cards   map[string][]byte // Latest view of each card as JSON, by card ID
+	tokens  map[string]string // Secret held by each card's player, by card ID
+	called  map[string]game.Item
+	clients map[*client]bool
+	http    *http.Server
+}
+
+// client is a browser following the game. Each channel holds only the
+// latest unsent data, since browsers redraw from it whole.
+type client struct {
+	card  string      // Card the browser plays; empty for the board alone
+	board chan []byte // Board changes
+	view  chan []byte // Changes to the card
+}
+
+// replace queues data on c in place of anything not yet sent
+func replace(c chan []byte, data []byte) {
+	select {
+	case <-c:
+	default:
+	}
+	c <- data
+}
+
+// New creates a server for the game run by engine
+func New(engine *game.Engine, images ImageSource) *Server {
+	s := &Server{
+		engine:  engine,
+		images:  images,
+		clients: make(map[*client]bool),
+		tokens:  make(map[string]string),
+	}
+	s.update(engine.Snapshot())
+	engine.Subscribe(s.update)
+	return s
+}
+
+// Handler returns the board's routes
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.FS(ui.Files)))
+	mux.HandleFunc("/events", s.handleEvents)
+	mux.HandleFunc("/api/board", s.handleBoard)
+	mux.HandleFunc("/images/", s.handleImage)
+	mux.HandleFunc("/api/cards", s.handleJoin)
+	mux.HandleFunc("/api/cards/", s.handleCardAPI)
+	mux.HandleFunc("/cards/", s.handleCardPage)
+	return mux
+}
+
+// Start listens on addr and serves in the background
+func (s *Server) Start(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("error starting web server: %v", err)
+	}
+	s.mu.Lock()
+	s.http = &http.Server{Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	srv := s.http
+	s.mu.Unlock()
+
+	logger.Info("web board started", "addr", ln.Addr().String())
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("web server stopped", "err", err)
+		}
+	}()
+	return nil
+}
+
+// Shutdown stops the server, closing every event stream
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	srv := s.http
+	s.http = nil
+	for c := range s.clients {
+		close(c.board)
+		delete(s.clients, c)
+	}
+	s.mu.Unlock()
+
+	if srv == nil {
+		return nil
+	}
+	return srv.Shutdown(ctx)
+}
+
+// update rebuilds the board and the card views from the engine, and sends
+// browsers whatever changed for them: the board to every browser, and a
+// card only to the browsers playing it
+func (s *Server) update(snap game.Snapshot) {
+	board := Board{
+		State:   snap.State.String(),
+		Round:   snap.Round,
+		Pattern: snap.Pattern.String(),
+		Calls:   make([]BoardCall, len(snap.Calls)),
+	}
+	called := make(map[string]game.Item, len(snap.Calls))
+	for i, c := range snap.Calls {
+		board.Calls[i] = BoardCall{Number: c.Number, ID: c.Item.ID, Caption: c.Item.Caption, Time: c.Time, Corrected: c.Corrected}
+		called[c.Item.ID] = c.Item
+	}
+	data, err := json.Marshal(board)
+	if err != nil {
+		logger.Error("cannot encode board", "err", err)
+		return
+	}
+	views := make(map[string][]byte, len(snap.Cards))
+	for _, card := range snap.Cards {
+		if views[card.ID], err = json.Marshal(cardView(card, snap.Pending)); err != nil {
+			logger.Error("cannot encode card", "card", card.ID, "err", err)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	boardChanged := !bytes.Equal(data, s.board)
+	s.board = data
+	s.called = called
+	for c := range s.clients {
+		if boardChanged {
+			replace(c.board, data)
+		}
+		if view, ok := views[c.card]; ok && !bytes.Equal(view, s.cards[c.card]) {
+			replace(c.view, view)
+		}
+	}
+	s.cards = views
+}
+
+// handleBoard returns the current board as JSON
+func (s *Server) handleBoard(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	data := s.board
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(data)
+}
+
+// handleEvents streams the board to a browser: once on connect, then after
+// every change. A browser playing a card names it with ?card=<id>, and is
+// also sent the card whenever it changes.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	c := &client{card: r.URL.Query().Get("card"), board: make(chan []byte, 1), view: make(chan []byte, 1)}
+	s.mu.Lock()
+	c.board <- s.board
+	if view, ok := s.cards[c.card]; ok {
+		c.view <- view
+	}
+	s.clients[c] = true
+	count := len(s.clients)
+	s.mu.Unlock()
+	logger.Debug("board viewer connected", "remote", r.RemoteAddr, "viewers", count)
+
+	defer func() {
+		s.mu.Lock()
+		if s.clients[c] {
+			delete(s.clients, c)
+			close(c.board)
+		}
+		s.mu.Unlock()
+		logger.Debug("board viewer disconnected", "remote", r.RemoteAddr)
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Connection", "keep-alive")
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case data, ok := <-c.board:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(w, "event: board\ndata: %s\n\n", data)
+		case data := <-c.view:
+			fmt.Fprintf(w, "event: card\ndata: %s\n\n", data)
+		case <-ticker.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// handleImage serves the image of a called item. Items not yet called are
+// not served, so nobody can look ahead in the deck.
+func (s *Server) handleImage(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/images/")
+	s.mu.Lock()
+	item, ok := s.called[id]
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	data, ok := s.images(item)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", http.DetectContentType(data))
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	w.Write(data)
+}
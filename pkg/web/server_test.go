@@ -0,0 +1,202 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"holidaybingo/pkg/game"
+)
+
+// stream reads event names from a browser's event stream
+func stream(t *testing.T, url string) <-chan string {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	events := make(chan string, 16)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if name := strings.TrimPrefix(scanner.Text(), "event: "); name != scanner.Text() {
+				events <- name
+			}
+		}
+	}()
+	return events
+}
+
+// next returns the next event name, or "" if none arrives soon
+func next(events <-chan string) string {
+	select {
+	case name := <-events:
+		return name
+	case <-time.After(300 * time.Millisecond):
+		return ""
+	}
+}
+
+// Marking a square sends the card to the browsers playing it, and nothing
+// to anyone else
+func TestMarkOnlyUpdatesItsCard(t *testing.T) {
+	e := game.NewEngine()
+	e.NewGame(1, []game.Item{{ID: "a"}, {ID: "b"}}, false)
+	e.Call()
+	card := game.Card{ID: "AB123", Player: "Ann"}
+	card.Squares[0] = "a"
+	if err := e.AddCard(card); err != nil {
+		t.Fatal(err)
+	}
+	other := game.Card{ID: "CD456", Player: "Bob"}
+	if err := e.AddCard(other); err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(e, func(game.Item) ([]byte, bool) { return nil, false })
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(func() {
+		s.Shutdown(context.Background()) // Ends the event streams
+		ts.Close()
+	})
+	time.Sleep(100 * time.Millisecond) // Let the engine's snapshots arrive
+
+	board := stream(t, ts.URL+"/events")
+	player := stream(t, ts.URL+"/events?card=AB123")
+	bystander := stream(t, ts.URL+"/events?card=CD456")
+	if got := next(board); got != "board" {
+		t.Fatalf("board viewer got %q on connect, want board", got)
+	}
+	for _, events := range []<-chan string{player, bystander} {
+		// Either may come first
+		got := []string{next(events), next(events)}
+		sort.Strings(got)
+		if got[0] != "board" || got[1] != "card" {
+			t.Fatalf("player got %q on connect, want board and card", got)
+		}
+	}
+
+	if _, err := e.Mark("AB123", 0, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := next(player); got != "card" {
+		t.Errorf("player got %q after a mark, want card", got)
+	}
+	if got := next(board); got != "" {
+		t.Errorf("board viewer got %q after a mark, want nothing", got)
+	}
+	if got := next(bystander); got != "" {
+		t.Errorf("other player got %q after a mark, want nothing", got)
+	}
+}
+
+// dealer deals the same card every time
+type dealer struct{ card game.Card }
+
+func (d dealer) Deal() (game.Card, error)         { return d.card, nil }
+func (d dealer) Printed(string) (game.Card, bool) { return game.Card{}, false }
+func (d dealer) Page(game.Card, func(int) string) (string, error) {
+	return "", nil
+}
+
+// post sends body as JSON with an optional card token and returns the
+// status and decoded response
+func post(t *testing.T, url, token, body string) (int, joinResponse) {
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set(tokenHeader, token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var joined joinResponse
+	json.NewDecoder(resp.Body).Decode(&joined)
+	return resp.StatusCode, joined
+}
+
+// Only the player who joined with a card can rejoin with it, mark it or
+// claim on it
+func TestCardNeedsItsToken(t *testing.T) {
+	e := game.NewEngine()
+	e.NewGame(1, []game.Item{{ID: "a"}}, false)
+	e.Call()
+	card := game.Card{ID: "AB123"}
+	card.Squares[0] = "a"
+
+	s := New(e, func(game.Item) ([]byte, bool) { return nil, false })
+	s.SetDealer(dealer{card})
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(func() {
+		s.Shutdown(context.Background())
+		ts.Close()
+	})
+
+	status, joined := post(t, ts.URL+"/api/cards", "", `{"player": "Ann"}`)
+	if status != http.StatusCreated || joined.ID != "AB123" || joined.Token == "" {
+		t.Fatalf("join returned %d %+v, want card AB123 with a token", status, joined)
+	}
+
+	tests := []struct {
+		name   string
+		url    string
+		token  string
+		body   string
+		status int
+	}{
+		{"rejoin without token", "/api/cards", "", `{"player": "Ann", "card_id": "ab123"}`, http.StatusForbidden},
+		{"rejoin with wrong token", "/api/cards", "", `{"player": "Ann", "card_id": "AB123", "token": "x"}`, http.StatusForbidden},
+		{"rejoin with token", "/api/cards", "", `{"player": "Ann", "card_id": "AB123", "token": "` + joined.Token + `"}`, http.StatusOK},
+		{"mark without token", "/api/cards/AB123/marks", "", `{"square": 0, "marked": true}`, http.StatusForbidden},
+		{"claim with wrong token", "/api/cards/AB123/claim", "x", `{}`, http.StatusForbidden},
+		{"mark with token", "/api/cards/AB123/marks", joined.Token, `{"square": 0, "marked": true}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if status, _ := post(t, ts.URL+tt.url, tt.token, tt.body); status != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.name, status, tt.status)
+		}
+	}
+	if c, _ := e.Card("AB123"); !c.Marked[0] {
+		t.Error("square not marked by the card's player")
+	}
+}
+
+// A card restored from the journal has no token yet, so its player can
+// rejoin by name
+func TestRejoinRestoredCardByName(t *testing.T) {
+	e := game.NewEngine()
+	e.NewGame(1, []game.Item{{ID: "a"}}, false)
+	if err := e.AddCard(game.Card{ID: "AB123", Player: "Ann"}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(e, func(game.Item) ([]byte, bool) { return nil, false })
+	s.SetDealer(dealer{})
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(func() {
+		s.Shutdown(context.Background())
+		ts.Close()
+	})
+
+	if status, _ := post(t, ts.URL+"/api/cards", "", `{"player": "Bob", "card_id": "AB123"}`); status != http.StatusForbidden {
+		t.Errorf("another player rejoining got status %d, want %d", status, http.StatusForbidden)
+	}
+	status, joined := post(t, ts.URL+"/api/cards", "", `{"player": "ann", "card_id": "AB123"}`)
+	if status != http.StatusOK || joined.Token == "" {
+		t.Fatalf("player rejoining got %d %+v, want the card with a token", status, joined)
+	}
+	if status, _ := post(t, ts.URL+"/api/cards", "", `{"player": "Ann", "card_id": "AB123"}`); status != http.StatusForbidden {
+		t.Errorf("rejoining by name after a token was issued got status %d, want %d", status, http.StatusForbidden)
+	}
+}
@@ -1,29 +1,39 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+
+	"holidaybingo/pkg/config"
+	"holidaybingo/pkg/provider"
+	"holidaybingo/pkg/secrets"
 	"holidaybingo/pkg/unsplash"
-	"github.com/joho/godotenv"
 )
 
 func main() {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Fatalf("Error loading .env file: %v", err)
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Error loading configuration: %v", err)
+	}
+
+	// The API key is the only setting this needs
+	apiKey, err := secrets.Default(true).Get(unsplash.APIKeyName)
+	if err != nil {
+		log.Fatalf("Error reading API key: %v", err)
 	}
 
 	// Fetch a photo from Unsplash
-	photoData, err := unsplash.GetPhoto()
+	photo, err := unsplash.Provider{APIKey: apiKey}.Next(context.Background())
 	if err != nil {
 		log.Fatalf("Error fetching photo: %v", err)
 	}
 
 	// Save the photo
-	err = unsplash.SavePhoto(photoData)
+	imgPath, err := provider.Save(cfg.ImageDir, photo)
 	if err != nil {
 		log.Fatalf("Error saving photo: %v", err)
 	}
 
-	fmt.Println("Image successfully fetched and saved!")
+	fmt.Printf("Image successfully fetched and saved to %s!\n", imgPath)
 }
@@ -0,0 +1,107 @@
+// Makes a card rendered from the card template playable: squares are
+// marked on the server, and the Bingo button sends a claim to the host.
+(function () {
+    const id = decodeURIComponent(location.pathname.split('/')[2]);
+    const api = '/api/cards/' + encodeURIComponent(id);
+    const free = 12;
+    // Given when the player joined; only its holder can mark the card
+    const token = localStorage.getItem('holidaybingo.token.' + id) || '';
+    let card = null;
+
+    function cell(square) {
+        return document.getElementById('cell-' + Math.floor(square / 5) + '-' + (square % 5));
+    }
+
+    // Player panel below the card
+    const panel = document.createElement('div');
+    panel.id = 'player';
+    const lastCall = document.createElement('div');
+    lastCall.id = 'last-call';
+    const bingo = document.createElement('button');
+    bingo.id = 'bingo';
+    bingo.textContent = 'Bingo!';
+    const message = document.createElement('div');
+    message.id = 'message';
+    panel.append(lastCall, bingo, message);
+    document.body.append(panel);
+
+    function apply(next) {
+        card = next;
+        for (let square = 0; square < 25; square++) {
+            cell(square).classList.toggle('marked', square !== free && card.marked[square]);
+        }
+        bingo.disabled = card.claimed;
+        if (card.claimed) {
+            message.textContent = 'Your claim is with the host, ' + card.player + '. Hold on...';
+        } else if (message.dataset.claimed) {
+            message.textContent = 'The host has checked your claim.';
+        }
+        message.dataset.claimed = card.claimed ? 'yes' : '';
+    }
+
+    async function send(path, body) {
+        const response = await fetch(api + path, {
+            method: body === undefined ? 'GET' : 'POST',
+            headers: { 'Content-Type': 'application/json', 'X-Card-Token': token },
+            body: body === undefined ? undefined : JSON.stringify(body),
+        });
+        if (!response.ok) {
+            throw new Error((await response.text()).trim());
+        }
+        apply(await response.json());
+    }
+
+    function refresh() {
+        send('').catch(err => { message.textContent = err.message; });
+    }
+
+    function mark(square, marked) {
+        cell(square).classList.toggle('marked', marked);
+        return send('/marks', { square: square, marked: marked }).catch(err => {
+            message.textContent = err.message;
+            refresh();
+        });
+    }
+
+    for (let square = 0; square < 25; square++) {
+        if (square !== free) {
+            cell(square).addEventListener('click', () => {
+                if (card) {
+                    mark(square, !cell(square).classList.contains('marked'));
+                }
+            });
+        }
+    }
+
+    // The template's Reset Card button
+    window.resetCard = function () {
+        if (!card || !confirm('Clear every mark on this card?')) {
+            return;
+        }
+        card.marked.forEach((marked, square) => {
+            if (marked && square !== free) {
+                mark(square, false);
+            }
+        });
+    };
+
+    bingo.addEventListener('click', () => {
+        bingo.disabled = true;
+        send('/claim', {}).catch(err => {
+            message.textContent = err.message;
+            bingo.disabled = false;
+        });
+    });
+
+    // Follow the calls, and pick up changes to this card made elsewhere,
+    // such as auto-daub, a checked claim or the marks cleared for a new round
+    const events = new EventSource('/events?card=' + encodeURIComponent(id));
+    events.addEventListener('board', e => {
+        const board = JSON.parse(e.data);
+        const last = board.calls[board.calls.length - 1];
+        lastCall.textContent = last ? 'Last call: #' + last.number + ' ' + last.caption : 'Waiting for the first call...';
+    });
+    events.addEventListener('card', e => apply(JSON.parse(e.data)));
+    refresh();
+    events.onerror = () => { lastCall.textContent = 'Reconnecting...'; };
+})();
@@ -0,0 +1,9 @@
+// Package ui holds the browser pages served by the caller app
+package ui
+
+import "embed"
+
+// Files holds the pages and their assets
+//
+//go:embed index.html play.html card.js card.css
+var Files embed.FS